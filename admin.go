@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/bafto/FindFavouriteSong/db"
+	"github.com/gin-gonic/gin"
+)
+
+type SessionCheckResult struct {
+	Session  int64    `json:"session"`
+	User     string   `json:"user"`
+	Problems []string `json:"problems"`
+	Error    *string  `json:"error,omitempty"`
+}
+
+type SessionScanResult struct {
+	Checked  int                  `json:"checked"`
+	Repaired bool                 `json:"repaired"`
+	Sessions []SessionCheckResult `json:"sessions"` // only sessions with problems
+}
+
+// reports broken sessions without changing them
+func (app *App) checkSessionsHandler(c *gin.Context) {
+	app.scanSessions(c, false)
+}
+
+// repairs all broken sessions
+func (app *App) repairSessionsHandler(c *gin.Context) {
+	app.scanSessions(c, true)
+}
+
+func (app *App) scanSessions(c *gin.Context, repair bool) {
+	logger := getLogger(c, "repair", repair)
+
+	sessions, err := app.queries.GetAllSessions(c.Request.Context())
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, fmt.Errorf("could not load sessions from DB: %w", err))
+		return
+	}
+
+	result := SessionScanResult{
+		Checked:  len(sessions),
+		Repaired: repair,
+		Sessions: []SessionCheckResult{},
+	}
+	for _, session := range sessions {
+		checkResult := app.scanSession(c.Request.Context(), logger.With("session-id", session.ID), session, repair)
+		if len(checkResult.Problems) > 0 || checkResult.Error != nil {
+			result.Sessions = append(result.Sessions, checkResult)
+		}
+	}
+
+	logger.Info("scanned sessions", "checked", result.Checked, "broken", len(result.Sessions))
+	c.JSON(http.StatusOK, result)
+}
+
+// every session is checked in its own transaction, so one
+// session that can't be repaired doesn't affect the others
+// checking makes the same changes as repairing, e.g. advancing the round,
+// but the transaction is only committed to repair the session
+func (app *App) scanSession(ctx context.Context, logger *slog.Logger, session db.Session, repair bool) SessionCheckResult {
+	result := SessionCheckResult{Session: session.ID, User: session.User, Problems: []string{}}
+	fail := func(err error) SessionCheckResult {
+		logger.Warn("could not check session", "err", err)
+		errStr := err.Error()
+		result.Error = &errStr
+		return result
+	}
+
+	// the session might be played right now
+	unlock := app.lockSession(session.ID)
+	defer unlock()
+
+	tx, err := app.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fail(fmt.Errorf("failed to create DB transaction: %w", err))
+	}
+	defer tx.Rollback()
+	queries := app.queries.WithTx(tx)
+
+	// it might have changed since all sessions were loaded
+	session, err = queries.GetSession(ctx, session.ID)
+	if err != nil {
+		return fail(fmt.Errorf("could not load session from DB: %w", err))
+	}
+
+	problems, err := repairSession(ctx, queries, &session)
+	if problems != nil {
+		result.Problems = problems
+	}
+	if err != nil {
+		return fail(err)
+	}
+
+	if !repair || len(problems) == 0 {
+		return result
+	}
+
+	if _, err := commitTransaction(tx); err != nil {
+		return fail(err)
+	}
+	logger.Info("repaired session", "problems", problems)
+	return result
+}
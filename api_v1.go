@@ -0,0 +1,767 @@
+package main
+
+import (
+	"database/sql"
+	_ "embed"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/bafto/FindFavouriteSong/db"
+	"github.com/bafto/FindFavouriteSong/tournament"
+	"github.com/gin-gonic/gin"
+)
+
+// the versioned JSON API under /api/v1, described by openapi.json
+// in contrast to the rest of /api it never redirects and always answers errors with an APIError
+
+//go:embed openapi.json
+var openapiSpec []byte
+
+type APIError struct {
+	Status int    `json:"status"`
+	Error  string `json:"error"`
+}
+
+// like c.AbortWithError, but with a JSON body
+func abortWithAPIError(c *gin.Context, status int, err error) {
+	c.Error(err)
+	c.AbortWithStatusJSON(status, APIError{Status: status, Error: err.Error()})
+}
+
+type APIUser struct {
+	ID             string `json:"id"`
+	CurrentSession *int64 `json:"current_session"`
+}
+
+type APIPlaylist struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	Url  string `json:"url"`
+}
+
+type APIItem struct {
+	ID      string `json:"id"`
+	Title   string `json:"title"`
+	Artists string `json:"artists"`
+	Image   string `json:"image"`
+}
+
+type APIRankingItem struct {
+	Position int64 `json:"position"`
+	APIItem
+}
+
+type APISession struct {
+	ID         int64     `json:"id"`
+	Playlist   string    `json:"playlist"`
+	Mode       string    `json:"mode"`
+	Rounds     int64     `json:"rounds"`
+	RandomSeed int64     `json:"random_seed"`
+	Round      int64     `json:"round"`
+	Winner     *string   `json:"winner"`
+	Created    time.Time `json:"created"`
+	InviteCode string    `json:"invite_code,omitempty"` // only set for group sessions
+}
+
+type APISessionState struct {
+	Round   int64        `json:"round"`
+	Matches int64        `json:"matches"` // matches played in the current round
+	Pair    []APIItem    `json:"pair"`    // null once the session is decided
+	Winner  *APIItem     `json:"winner"`
+	Group   *GroupStatus `json:"group,omitempty"`
+}
+
+type APIMatch struct {
+	ID          int64     `json:"id"`
+	Round       int64     `json:"round"`
+	Winner      string    `json:"winner"`
+	Loser       string    `json:"loser"`
+	Outcome     string    `json:"outcome"`
+	RatingDelta *float64  `json:"rating_delta"` // null for skipped pairs
+	Created     time.Time `json:"created"`
+}
+
+type APIUpdateUser struct {
+	CurrentSession *int64 `json:"current_session"`
+}
+
+type APINewPlaylist struct {
+	Url string `json:"url" binding:"required"`
+}
+
+type APINewSession struct {
+	Playlist    string `json:"playlist" binding:"required"` // id of a playlist added by the user
+	Mode        string `json:"mode"`
+	Rounds      int64  `json:"rounds"`
+	Seeded      bool   `json:"seeded"`
+	RandomSeed  *int64 `json:"random_seed"`
+	Group       bool   `json:"group"`
+	VoteTimeout int64  `json:"vote_timeout"` // seconds, group sessions only
+}
+
+type APINewMatch struct {
+	Winner  string `json:"winner" binding:"required"`
+	Loser   string `json:"loser" binding:"required"`
+	Outcome string `json:"outcome"`
+}
+
+func openapiHandler(c *gin.Context) {
+	c.Data(http.StatusOK, "application/json", openapiSpec)
+}
+
+func (app *App) apiGetUserHandler(c *gin.Context) {
+	user, err := app.getActiveUser(c)
+	if err != nil {
+		abortWithAPIError(c, http.StatusInternalServerError, fmt.Errorf("failed to get activeUser, user not found: %w", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, newAPIUser(user))
+}
+
+// selects or leaves the current session of the user
+// like with /api/select_new_playlist a session can only be left if the user has less than max_incomplete_sessions other incomplete sessions
+func (app *App) apiUpdateUserHandler(c *gin.Context) {
+	logger, user, tx, queries, err := app.getLoggerUserTransactionQueries(c)
+	if err != nil {
+		abortWithAPIError(c, http.StatusInternalServerError, err)
+		return
+	}
+	defer tx.Rollback()
+
+	var update APIUpdateUser
+	if err := c.ShouldBindJSON(&update); err != nil {
+		abortWithAPIError(c, http.StatusBadRequest, fmt.Errorf("invalid body: %w", err))
+		return
+	}
+
+	if user.CurrentSession.Valid {
+		sessions, err := queries.GetNonActiveUserSessions(c.Request.Context(), db.GetNonActiveUserSessionsParams{
+			User:          user.ID,
+			Activesession: user.CurrentSessionNotNull(),
+		})
+		if err != nil {
+			abortWithAPIError(c, http.StatusInternalServerError, fmt.Errorf("error retrieving sessions for user: %w", err))
+			return
+		}
+		if len(sessions) >= max_incomplete_sessions {
+			abortWithAPIError(c, http.StatusConflict, fmt.Errorf("too many incomplete sessions, delete one first"))
+			return
+		}
+	}
+
+	currentSession := sql.NullInt64{Valid: false}
+	if update.CurrentSession != nil {
+		session, err := queries.GetSession(c.Request.Context(), *update.CurrentSession)
+		if err != nil || session.User != user.ID {
+			abortWithAPIError(c, http.StatusNotFound, fmt.Errorf("session %d does not exist", *update.CurrentSession))
+			return
+		}
+		if session.Winner.Valid {
+			abortWithAPIError(c, http.StatusConflict, fmt.Errorf("session %d is already decided", session.ID))
+			return
+		}
+		currentSession = sql.NullInt64{Int64: session.ID, Valid: true}
+	}
+
+	if err := queries.SetUserSession(c.Request.Context(), db.SetUserSessionParams{
+		CurrentSession: currentSession,
+		ID:             user.ID,
+	}); err != nil {
+		abortWithAPIError(c, http.StatusInternalServerError, fmt.Errorf("failed to set user session: %w", err))
+		return
+	}
+
+	if status, err := commitTransaction(tx); err != nil {
+		abortWithAPIError(c, status, err)
+		return
+	}
+	user.CurrentSession = currentSession
+	logger.Debug("updated current session", "session-id", currentSession.Int64, "valid", currentSession.Valid)
+
+	c.JSON(http.StatusOK, newAPIUser(user))
+}
+
+func (app *App) apiGetPlaylistsHandler(c *gin.Context) {
+	user, err := app.getActiveUser(c)
+	if err != nil {
+		abortWithAPIError(c, http.StatusInternalServerError, fmt.Errorf("failed to get activeUser, user not found: %w", err))
+		return
+	}
+
+	playlists, err := app.queries.GetPlaylistsForUser(c.Request.Context(), user.ID)
+	if err != nil {
+		abortWithAPIError(c, http.StatusInternalServerError, fmt.Errorf("Error loading playlist for user: %w", err))
+		return
+	}
+
+	result := make([]APIPlaylist, len(playlists))
+	for i, playlist := range playlists {
+		result[i] = newAPIPlaylist(playlist)
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// adds a spotify playlist or refreshes its items if it was added before
+func (app *App) apiAddPlaylistHandler(c *gin.Context) {
+	logger, user, tx, queries, err := app.getLoggerUserTransactionQueries(c)
+	if err != nil {
+		abortWithAPIError(c, http.StatusInternalServerError, err)
+		return
+	}
+	defer tx.Rollback()
+
+	var newPlaylist APINewPlaylist
+	if err := c.ShouldBindJSON(&newPlaylist); err != nil {
+		abortWithAPIError(c, http.StatusBadRequest, fmt.Errorf("invalid body: %w", err))
+		return
+	}
+
+	playlistId, status, err := app.addPlaylistToDB(c.Request.Context(), logger, user, queries, newPlaylist.Url)
+	if err != nil {
+		abortWithAPIError(c, status, err)
+		return
+	}
+
+	playlist, err := queries.GetPlaylist(c.Request.Context(), playlistId)
+	if err != nil {
+		abortWithAPIError(c, http.StatusInternalServerError, fmt.Errorf("could not get playlist from db: %w", err))
+		return
+	}
+
+	if status, err := commitTransaction(tx); err != nil {
+		abortWithAPIError(c, status, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, newAPIPlaylist(playlist))
+}
+
+// imports a M3U, CSV or JSON file uploaded as multipart form
+func (app *App) apiImportPlaylistHandler(c *gin.Context) {
+	logger, user, tx, queries, err := app.getLoggerUserTransactionQueries(c)
+	if err != nil {
+		abortWithAPIError(c, http.StatusInternalServerError, err)
+		return
+	}
+	defer tx.Rollback()
+
+	playlistId, status, err := importPlaylistFromForm(c, logger, user, queries)
+	if err != nil {
+		abortWithAPIError(c, status, err)
+		return
+	}
+
+	playlist, err := queries.GetPlaylist(c.Request.Context(), playlistId)
+	if err != nil {
+		abortWithAPIError(c, http.StatusInternalServerError, fmt.Errorf("could not get playlist from db: %w", err))
+		return
+	}
+
+	if status, err := commitTransaction(tx); err != nil {
+		abortWithAPIError(c, status, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, newAPIPlaylist(playlist))
+}
+
+func (app *App) apiPlaylistStatisticsHandler(c *gin.Context) {
+	user, err := app.getActiveUser(c)
+	if err != nil {
+		abortWithAPIError(c, http.StatusInternalServerError, fmt.Errorf("failed to get activeUser, user not found: %w", err))
+		return
+	}
+
+	result, err := app.queries.GetStatistics1(c.Request.Context(), db.GetStatistics1Params{
+		User:     user.ID,
+		Playlist: c.Param("playlist"),
+	})
+	if err != nil {
+		abortWithAPIError(c, http.StatusInternalServerError, fmt.Errorf("failed to retreive statistics: %w", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, Statistics1ToJson(result))
+}
+
+func (app *App) apiPlaylistRatingsHandler(c *gin.Context) {
+	user, err := app.getActiveUser(c)
+	if err != nil {
+		abortWithAPIError(c, http.StatusInternalServerError, fmt.Errorf("failed to get activeUser, user not found: %w", err))
+		return
+	}
+
+	result, err := app.queries.GetRatingLeaderboard(c.Request.Context(), db.GetRatingLeaderboardParams{
+		DefaultRating: tournament.DefaultRating,
+		User:          user.ID,
+		Playlist:      c.Param("playlist"),
+	})
+	if err != nil {
+		abortWithAPIError(c, http.StatusInternalServerError, fmt.Errorf("failed to retreive ratings: %w", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, RatingLeaderboardToJson(result))
+}
+
+func (app *App) apiGetSessionsHandler(c *gin.Context) {
+	user, err := app.getActiveUser(c)
+	if err != nil {
+		abortWithAPIError(c, http.StatusInternalServerError, fmt.Errorf("failed to get activeUser, user not found: %w", err))
+		return
+	}
+
+	sessions, err := app.queries.GetSessionsForUser(c.Request.Context(), user.ID)
+	if err != nil {
+		abortWithAPIError(c, http.StatusInternalServerError, fmt.Errorf("error retrieving sessions for user: %w", err))
+		return
+	}
+
+	result := make([]APISession, len(sessions))
+	for i, session := range sessions {
+		if result[i], err = newAPISession(c, app.queries, session); err != nil {
+			abortWithAPIError(c, http.StatusInternalServerError, err)
+			return
+		}
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// creates a session and makes it the current session of the user
+func (app *App) apiAddSessionHandler(c *gin.Context) {
+	logger, user, tx, queries, err := app.getLoggerUserTransactionQueries(c)
+	if err != nil {
+		abortWithAPIError(c, http.StatusInternalServerError, err)
+		return
+	}
+	defer tx.Rollback()
+
+	if user.CurrentSession.Valid {
+		abortWithAPIError(c, http.StatusConflict, fmt.Errorf("active session already exists"))
+		return
+	}
+
+	newSession := APINewSession{Mode: tournament.ModeKnockout}
+	if err := c.ShouldBindJSON(&newSession); err != nil {
+		abortWithAPIError(c, http.StatusBadRequest, fmt.Errorf("invalid body: %w", err))
+		return
+	}
+
+	playlists, err := queries.GetPlaylistsForUser(c.Request.Context(), user.ID)
+	if err != nil {
+		abortWithAPIError(c, http.StatusInternalServerError, fmt.Errorf("Error loading playlist for user: %w", err))
+		return
+	}
+	if !slices.ContainsFunc(playlists, func(playlist db.Playlist) bool { return playlist.ID == newSession.Playlist }) {
+		abortWithAPIError(c, http.StatusNotFound, fmt.Errorf("playlist %s was not added by the user", newSession.Playlist))
+		return
+	}
+
+	if _, err := tournament.ForMode(newSession.Mode); err != nil {
+		abortWithAPIError(c, http.StatusBadRequest, err)
+		return
+	}
+	if newSession.Rounds < 0 {
+		abortWithAPIError(c, http.StatusBadRequest, fmt.Errorf("rounds must be a positive number"))
+		return
+	}
+
+	randomSeed := rand.Int64()
+	if newSession.RandomSeed != nil {
+		randomSeed = *newSession.RandomSeed
+	}
+
+	var groupVoteTimeout time.Duration
+	if newSession.Group {
+		groupVoteTimeout = app.config.GroupVoteTimeout
+		if newSession.VoteTimeout < 0 {
+			abortWithAPIError(c, http.StatusBadRequest, fmt.Errorf("vote_timeout must be a positive number"))
+			return
+		}
+		if newSession.VoteTimeout > 0 {
+			groupVoteTimeout = time.Duration(newSession.VoteTimeout) * time.Second
+		}
+	}
+
+	if status, err := prepareNewSession(c.Request.Context(), logger, user, queries, tx, newSession.Playlist, db.AddSessionParams{
+		Mode:       newSession.Mode,
+		Rounds:     newSession.Rounds,
+		RandomSeed: randomSeed,
+	}, newSession.Seeded, groupVoteTimeout); err != nil {
+		abortWithAPIError(c, status, err)
+		return
+	}
+
+	// the transaction was committed by prepareNewSession
+	result, err := app.loadAPISession(c, user.CurrentSession.Int64)
+	if err != nil {
+		abortWithAPIError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.Header("Location", "/api/v1/sessions/"+strconv.FormatInt(result.ID, 10))
+	c.JSON(http.StatusCreated, result)
+}
+
+func (app *App) apiGetSessionHandler(c *gin.Context) {
+	user, err := app.getActiveUser(c)
+	if err != nil {
+		abortWithAPIError(c, http.StatusInternalServerError, fmt.Errorf("failed to get activeUser, user not found: %w", err))
+		return
+	}
+
+	session, status, err := getAPISession(c, app.queries, user)
+	if err != nil {
+		abortWithAPIError(c, status, err)
+		return
+	}
+
+	result, err := newAPISession(c, app.queries, session)
+	if err != nil {
+		abortWithAPIError(c, http.StatusInternalServerError, err)
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// the current session of the user has to be left first
+func (app *App) apiDeleteSessionHandler(c *gin.Context) {
+	logger, user, tx, queries, err := app.getLoggerUserTransactionQueries(c)
+	if err != nil {
+		abortWithAPIError(c, http.StatusInternalServerError, err)
+		return
+	}
+	defer tx.Rollback()
+
+	session, status, err := getAPISession(c, queries, user)
+	if err != nil {
+		abortWithAPIError(c, status, err)
+		return
+	}
+	if session.User != user.ID {
+		abortWithAPIError(c, http.StatusForbidden, fmt.Errorf("session does not belong to user"))
+		return
+	}
+	if user.CurrentSessionNotNull() == session.ID {
+		abortWithAPIError(c, http.StatusConflict, fmt.Errorf("the current session can not be deleted"))
+		return
+	}
+
+	// group members would be left in a session which doesn't exist anymore
+	if err := queries.ResetCurrentSessionForGroupMembers(c.Request.Context(), sql.NullInt64{Int64: session.ID, Valid: true}); err != nil {
+		abortWithAPIError(c, http.StatusInternalServerError, fmt.Errorf("unable to reset current session of group members: %w", err))
+		return
+	}
+	if err := deleteSession(c.Request.Context(), queries, session.ID); err != nil {
+		abortWithAPIError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	if status, err := commitTransaction(tx); err != nil {
+		abortWithAPIError(c, status, err)
+		return
+	}
+	logger.Debug("deleted session", "deleted-session", session.ID)
+
+	c.Status(http.StatusNoContent)
+}
+
+// the pair to be decided next
+func (app *App) apiGetPairHandler(c *gin.Context) {
+	app.apiPlaySession(c, "", "", "")
+}
+
+func (app *App) apiAddMatchHandler(c *gin.Context) {
+	newMatch := APINewMatch{Outcome: tournament.OutcomeWin}
+	if err := c.ShouldBindJSON(&newMatch); err != nil {
+		abortWithAPIError(c, http.StatusBadRequest, fmt.Errorf("invalid body: %w", err))
+		return
+	}
+
+	app.apiPlaySession(c, newMatch.Winner, newMatch.Loser, newMatch.Outcome)
+}
+
+// helper function for apiGetPairHandler and apiAddMatchHandler
+// only the current session of the user can be played
+func (app *App) apiPlaySession(c *gin.Context, winnerID, loserID, outcome string) {
+	logger, user, tx, queries, err := app.getLoggerUserTransactionQueries(c)
+	if err != nil {
+		abortWithAPIError(c, http.StatusInternalServerError, err)
+		return
+	}
+	defer tx.Rollback()
+
+	session, status, err := getAPICurrentSession(c, queries, user)
+	if err != nil {
+		abortWithAPIError(c, status, err)
+		return
+	}
+	logger = logger.With("session-id", session.ID)
+
+	state, status, err := app.playSession(c.Request.Context(), logger, user, tx, queries, &session, winnerID, loserID, outcome)
+	if err != nil {
+		abortWithAPIError(c, status, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, newAPISessionState(state))
+}
+
+func (app *App) apiGetMatchesHandler(c *gin.Context) {
+	user, err := app.getActiveUser(c)
+	if err != nil {
+		abortWithAPIError(c, http.StatusInternalServerError, fmt.Errorf("failed to get activeUser, user not found: %w", err))
+		return
+	}
+
+	session, status, err := getAPISession(c, app.queries, user)
+	if err != nil {
+		abortWithAPIError(c, status, err)
+		return
+	}
+
+	matches, err := app.queries.GetMatchesForSession(c.Request.Context(), session.ID)
+	if err != nil {
+		abortWithAPIError(c, http.StatusInternalServerError, fmt.Errorf("could not load matches from DB: %w", err))
+		return
+	}
+
+	result := make([]APIMatch, len(matches))
+	for i, match := range matches {
+		result[i] = newAPIMatch(match)
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// answers with the undone pair, which is to be decided again
+func (app *App) apiUndoMatchHandler(c *gin.Context) {
+	logger, user, tx, queries, err := app.getLoggerUserTransactionQueries(c)
+	if err != nil {
+		abortWithAPIError(c, http.StatusInternalServerError, err)
+		return
+	}
+	defer tx.Rollback()
+
+	session, status, err := getAPICurrentSession(c, queries, user)
+	if err != nil {
+		abortWithAPIError(c, status, err)
+		return
+	}
+	logger = logger.With("session-id", session.ID)
+
+	state, status, err := app.undoLatestMatch(c.Request.Context(), logger, tx, queries, &session)
+	if err != nil {
+		abortWithAPIError(c, status, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, newAPISessionState(state))
+}
+
+func (app *App) apiGetWinnerHandler(c *gin.Context) {
+	user, err := app.getActiveUser(c)
+	if err != nil {
+		abortWithAPIError(c, http.StatusInternalServerError, fmt.Errorf("failed to get activeUser, user not found: %w", err))
+		return
+	}
+
+	session, status, err := getAPISession(c, app.queries, user)
+	if err != nil {
+		abortWithAPIError(c, status, err)
+		return
+	}
+	if !session.Winner.Valid {
+		abortWithAPIError(c, http.StatusNotFound, fmt.Errorf("session is not decided yet"))
+		return
+	}
+
+	winner, err := app.queries.GetPlaylistItem(c.Request.Context(), session.Winner.String)
+	if err != nil {
+		abortWithAPIError(c, http.StatusInternalServerError, fmt.Errorf("winner not found in DB: %w", err))
+		return
+	}
+	c.JSON(http.StatusOK, newAPIItem(winner))
+}
+
+// only ranking sessions have a ranking, it is complete once the session is decided
+func (app *App) apiGetRankingHandler(c *gin.Context) {
+	user, err := app.getActiveUser(c)
+	if err != nil {
+		abortWithAPIError(c, http.StatusInternalServerError, fmt.Errorf("failed to get activeUser, user not found: %w", err))
+		return
+	}
+
+	session, status, err := getAPISession(c, app.queries, user)
+	if err != nil {
+		abortWithAPIError(c, status, err)
+		return
+	}
+	if session.Mode != tournament.ModeRanking {
+		abortWithAPIError(c, http.StatusNotFound, fmt.Errorf("only %s sessions have a ranking", tournament.ModeRanking))
+		return
+	}
+
+	ranking, err := app.queries.GetRankingForSession(c.Request.Context(), session.ID)
+	if err != nil {
+		abortWithAPIError(c, http.StatusInternalServerError, fmt.Errorf("failed to retreive ranking: %w", err))
+		return
+	}
+
+	result := make([]APIRankingItem, len(ranking))
+	for i, item := range ranking {
+		result[i] = APIRankingItem{
+			Position: item.Position,
+			APIItem: APIItem{
+				ID:      item.ID,
+				Title:   item.Title.String,
+				Artists: item.Artists.String,
+				Image:   item.Image.String,
+			},
+		}
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+func (app *App) apiSessionEventsHandler(c *gin.Context) {
+	user, err := app.getActiveUser(c)
+	if err != nil {
+		abortWithAPIError(c, http.StatusInternalServerError, fmt.Errorf("failed to get activeUser, user not found: %w", err))
+		return
+	}
+
+	session, status, err := getAPISession(c, app.queries, user)
+	if err != nil {
+		abortWithAPIError(c, status, err)
+		return
+	}
+
+	app.streamSessionEvents(c, getLogger(c, "session-id", session.ID), session.ID)
+}
+
+// loads the session from the session path parameter
+func getAPISession(c *gin.Context, queries db.Store, user *ActiveUser) (db.Session, int, error) {
+	sessionID, err := strconv.ParseInt(c.Param("session"), 10, 64)
+	if err != nil {
+		return db.Session{}, http.StatusBadRequest, fmt.Errorf("session must be a valid number")
+	}
+
+	session, err := queries.GetSession(c.Request.Context(), sessionID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return db.Session{}, http.StatusNotFound, fmt.Errorf("session %d does not exist", sessionID)
+	}
+	if err != nil {
+		return db.Session{}, http.StatusInternalServerError, fmt.Errorf("could not load session from DB: %w", err)
+	}
+
+	// not leaking which sessions exist
+	if !canAccessSession(user, &session) {
+		return db.Session{}, http.StatusNotFound, fmt.Errorf("session %d does not exist", sessionID)
+	}
+	return session, -1, nil
+}
+
+// like getAPISession, but the session has to be the current session of the user
+func getAPICurrentSession(c *gin.Context, queries db.Store, user *ActiveUser) (db.Session, int, error) {
+	session, status, err := getAPISession(c, queries, user)
+	if err != nil {
+		return session, status, err
+	}
+	if user.CurrentSessionNotNull() != session.ID {
+		return db.Session{}, http.StatusConflict, fmt.Errorf("session %d is not the current session, select it with PATCH /api/v1/me", session.ID)
+	}
+	return session, -1, nil
+}
+
+// loads the session outside of a transaction
+func (app *App) loadAPISession(c *gin.Context, sessionID int64) (APISession, error) {
+	session, err := app.queries.GetSession(c.Request.Context(), sessionID)
+	if err != nil {
+		return APISession{}, fmt.Errorf("could not load session from DB: %w", err)
+	}
+	return newAPISession(c, app.queries, session)
+}
+
+func newAPIUser(user *ActiveUser) APIUser {
+	result := APIUser{ID: user.ID}
+	if user.CurrentSession.Valid {
+		result.CurrentSession = &user.CurrentSession.Int64
+	}
+	return result
+}
+
+func newAPIPlaylist(playlist db.Playlist) APIPlaylist {
+	return APIPlaylist{
+		ID:   playlist.ID,
+		Name: playlist.Name.String,
+		Url:  playlist.Url.String,
+	}
+}
+
+func newAPIItem(item db.PlaylistItem) APIItem {
+	return APIItem{
+		ID:      item.ID,
+		Title:   item.Title.String,
+		Artists: item.Artists.String,
+		Image:   item.Image.String,
+	}
+}
+
+func newAPISession(c *gin.Context, queries db.Store, session db.Session) (APISession, error) {
+	result := APISession{
+		ID:         session.ID,
+		Playlist:   session.Playlist,
+		Mode:       session.Mode,
+		Rounds:     session.Rounds,
+		RandomSeed: session.RandomSeed,
+		Round:      session.CurrentRound,
+		Created:    session.CreationTimestamp.Time,
+	}
+	if session.Winner.Valid {
+		result.Winner = &session.Winner.String
+	}
+
+	group, err := queries.GetGroupSession(c.Request.Context(), session.ID)
+	if err == nil {
+		result.InviteCode = group.InviteCode
+	} else if !errors.Is(err, sql.ErrNoRows) {
+		return APISession{}, fmt.Errorf("could not load group session from DB: %w", err)
+	}
+	return result, nil
+}
+
+func newAPISessionState(state SessionState) APISessionState {
+	result := APISessionState{
+		Round:   state.Round,
+		Matches: state.Matches,
+		Group:   state.Group,
+	}
+	for _, item := range state.Pair {
+		result.Pair = append(result.Pair, newAPIItem(item))
+	}
+	if state.Winner != nil {
+		winner := newAPIItem(*state.Winner)
+		result.Winner = &winner
+	}
+	return result
+}
+
+func newAPIMatch(match db.Match) APIMatch {
+	result := APIMatch{
+		ID:      match.ID,
+		Round:   match.RoundNumber,
+		Winner:  match.Winner,
+		Loser:   match.Loser,
+		Outcome: match.Outcome,
+		Created: match.CreationTimestamp.Time,
+	}
+	if match.RatingDelta.Valid {
+		result.RatingDelta = &match.RatingDelta.Float64
+	}
+	return result
+}
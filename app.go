@@ -0,0 +1,215 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bafto/FindFavouriteSong/db"
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-contrib/sessions/cookie"
+	"github.com/gin-gonic/gin"
+	"github.com/zmb3/spotify/v2"
+	"golang.org/x/oauth2"
+)
+
+// creates a spotify client which authenticates with the tokens of tokens
+// tokens refreshes expired tokens, so the client should not cache them beyond their expiry
+type SpotifyClientFactory func(tokens oauth2.TokenSource) *spotify.Client
+
+// the state of one instance of the app, the handlers are its methods
+// several apps can be served from one process, e.g. in tests
+type App struct {
+	config           Config
+	db               *sql.DB
+	queries          db.Store
+	cookieStore      cookie.Store
+	spotifyOAuth     *oauth2.Config
+	newSpotifyClient SpotifyClientFactory
+
+	logins        SyncMap[string, pendingLogin]    // by oauth state
+	activeUsers   SyncMap[string, *loggedInUser]   // by user id
+	sessionLocks  [session_lock_stripes]sync.Mutex // see lockSession
+	sessionEvents EventBroker[int64]
+	userEvents    EventBroker[string]
+
+	backupMutex  sync.Mutex
+	backupStatus BackupStatus // of the latest scheduled backup, see backupTicker
+
+	groupTimersMutex sync.Mutex
+	groupTimers      map[int64]*time.Timer // pending vote timeouts by session, see scheduleGroupTimeout
+	groupTimeouts    sync.WaitGroup        // running vote timeouts
+
+	done chan struct{} // closed by Close
+}
+
+// conn has to be migrated already, it is not closed by the app
+// a nil spotifyClients uses the spotify api configured in config
+func NewApp(config Config, conn *sql.DB, spotifyClients SpotifyClientFactory) (*App, error) {
+	if config.LoginTimeout <= 0 {
+		return nil, fmt.Errorf("login_timeout must be positive")
+	}
+
+	authKey, encryptionKey, err := read_cookie_keys(config)
+	if err != nil {
+		return nil, err
+	}
+	cookieStore := cookie.NewStore(authKey, encryptionKey)
+	cookieStore.Options(sessions.Options{SameSite: http.SameSiteLaxMode})
+
+	queries, err := new_storage_backend(config.Datasource).newStore(context.Background(), conn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare DB queries: %w", err)
+	}
+
+	if spotifyClients == nil {
+		spotifyClients = NewSpotifyClientFactory(config)
+	}
+
+	app := &App{
+		config:           config,
+		db:               conn,
+		queries:          queries,
+		cookieStore:      cookieStore,
+		spotifyOAuth:     newSpotifyOAuthConfig(config),
+		newSpotifyClient: spotifyClients,
+		sessionEvents:    EventBroker[int64]{subscribers: map[int64]map[chan SessionEvent]struct{}{}},
+		userEvents:       EventBroker[string]{subscribers: map[string]map[chan SessionEvent]struct{}{}},
+		groupTimers:      map[int64]*time.Timer{},
+		done:             make(chan struct{}),
+	}
+	go app.sweepLogins(app.done)
+	return app, nil
+}
+
+// clients for config.Spotify_api_url
+func NewSpotifyClientFactory(config Config) SpotifyClientFactory {
+	apiURL := strings.TrimSuffix(config.Spotify_api_url, "/") + "/"
+	return func(tokens oauth2.TokenSource) *spotify.Client {
+		return spotify.New(
+			oauth2.NewClient(context.Background(), tokens),
+			spotify.WithRetry(true),
+			spotify.WithBaseURL(apiURL),
+		)
+	}
+}
+
+// stops the background work of the app and closes the prepared queries
+// the DB connection belongs to the caller
+func (app *App) Close() error {
+	app.groupTimersMutex.Lock()
+	close(app.done)
+	for _, timer := range app.groupTimers {
+		timer.Stop()
+	}
+	app.groupTimersMutex.Unlock()
+
+	// timeouts which already fired still use the queries
+	app.groupTimeouts.Wait()
+	return app.queries.Close()
+}
+
+// whether Close was called
+func (app *App) closed() bool {
+	select {
+	case <-app.done:
+		return true
+	default:
+		return false
+	}
+}
+
+func (app *App) addMiddleware(r interface {
+	Use(middleware ...gin.HandlerFunc) gin.IRoutes
+}, auth bool,
+) {
+	r.Use(gin.Recovery())
+	r.Use(gin.LoggerWithConfig(gin.LoggerConfig{
+		Formatter: gin_log_formatter,
+		Output:    io.Discard,
+	}))
+	r.Use(SlogMiddleware())
+	if auth {
+		r.Use(gin.BasicAuth(app.config.Users))
+		r.Use(sessions.Sessions(session_name, app.cookieStore))
+		r.Use(app.SpotifyAuthMiddleware())
+	}
+}
+
+// the routes of the app
+func (app *App) Router() *gin.Engine {
+	r := gin.New()
+
+	r.LoadHTMLGlob("*.gohtml")
+	root := r.Group("/")
+	api := r.Group("/api")
+	health := api.Group("/health")
+
+	app.addMiddleware(root, true)
+	app.addMiddleware(api, true)
+	app.addMiddleware(health, false)                    // no auth for healthcheck
+	admin := api.Group("/admin", app.AdminMiddleware()) // created after addMiddleware to inherit the auth of api
+	v1 := r.Group("/api/v1")                            // not below api, the JSON API has its own auth
+	app.addMiddleware(v1, false)
+
+	{
+		root.Static("/public", "./public")
+		root.GET("/", app.defaultHandler)
+		root.GET("/spotifyauthentication", app.authHandler)
+		root.GET("/select_song", app.selectSongPageHandler)
+		root.GET("/winner", app.winnerHandler)
+		root.GET("/ranking", app.rankingHandler)
+		root.GET("/stats", app.statsPageHandler)
+	}
+	{
+		api.POST("/select_playlist", app.selectPlaylistHandler)
+		api.POST("/import_playlist", app.importPlaylistHandler)
+		api.POST("/select_session", app.selectSessionHandler)
+		api.POST("/select_song", app.SessionLockMiddleware(), app.selectSongHandler)
+		api.POST("/undo_match", app.SessionLockMiddleware(), app.undoMatchHandler)
+		api.POST("/join_group_session", app.joinGroupSessionHandler)
+		api.GET("/session_events", app.sessionEventsHandler)
+		api.GET("/user_events", app.userEventsHandler)
+		api.GET("/select_new_playlist", app.selectNewPlaylistHandler)
+		api.GET("/playlist_statistics", app.playlistStatisticsHandler)
+		api.GET("/playlist_ratings", app.playlistRatingsHandler)
+	}
+	{
+		admin.GET("/check_sessions", app.checkSessionsHandler)
+		admin.POST("/repair_sessions", app.repairSessionsHandler)
+	}
+	{
+		v1.GET("/openapi.json", openapiHandler) // registered before the auth middleware
+		v1.Use(sessions.Sessions(session_name, app.cookieStore), app.APIAuthMiddleware())
+
+		v1.GET("/me", app.apiGetUserHandler)
+		v1.PATCH("/me", app.apiUpdateUserHandler)
+		v1.GET("/playlists", app.apiGetPlaylistsHandler)
+		v1.POST("/playlists", app.apiAddPlaylistHandler)
+		v1.POST("/playlists/import", app.apiImportPlaylistHandler)
+		v1.GET("/playlists/:playlist/statistics", app.apiPlaylistStatisticsHandler)
+		v1.GET("/playlists/:playlist/ratings", app.apiPlaylistRatingsHandler)
+		v1.GET("/sessions", app.apiGetSessionsHandler)
+		v1.POST("/sessions", app.apiAddSessionHandler)
+		v1.GET("/sessions/:session", app.apiGetSessionHandler)
+		v1.DELETE("/sessions/:session", app.apiDeleteSessionHandler)
+		v1.GET("/sessions/:session/pair", app.SessionLockMiddleware(), app.apiGetPairHandler)
+		v1.GET("/sessions/:session/matches", app.apiGetMatchesHandler)
+		v1.POST("/sessions/:session/matches", app.SessionLockMiddleware(), app.apiAddMatchHandler)
+		v1.DELETE("/sessions/:session/matches/latest", app.SessionLockMiddleware(), app.apiUndoMatchHandler)
+		v1.GET("/sessions/:session/winner", app.apiGetWinnerHandler)
+		v1.GET("/sessions/:session/ranking", app.apiGetRankingHandler)
+		v1.GET("/sessions/:session/events", app.apiSessionEventsHandler)
+	}
+	{
+		health.GET("", app.healthcheckHandler)
+		health.HEAD("", app.healthcheckHandler)
+	}
+
+	return r
+}
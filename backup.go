@@ -0,0 +1,213 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// scheduled backups are written to timestamped files in config.BackupDir
+// and pruned by the retention policy afterwards
+// the backup before migrations still goes to config.BackupPath, so it can be restored
+// if a migration fails
+
+const (
+	backup_file_prefix = "ffs-"
+	backup_time_format = "20060102T150405Z" // sorts like the time
+)
+
+type BackupStatus struct {
+	Healthy    bool       `json:"healthy"`
+	LastBackup *time.Time `json:"last-backup,omitempty"` // the newest verified backup
+	Backups    int        `json:"backups"`
+	Error      *string    `json:"error,omitempty"`
+}
+
+// backups are only scheduled if both backup_dir and backup_interval are set
+func scheduled_backups_enabled(config Config) bool {
+	return config.BackupDir != "" && config.BackupInterval > 0
+}
+
+// a backup in config.BackupDir
+type backupFile struct {
+	path string
+	time time.Time
+}
+
+// backs up the DB of the app every backup_interval, to be run concurrently
+// stops when done is closed
+func (app *App) backupTicker(done <-chan struct{}) {
+	ticker := time.NewTicker(app.config.BackupInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			_, err := rotating_backup_db(context.Background(), app.config, app.db)
+			if err != nil {
+				slog.Error("failed to backup db", "err", err)
+			}
+			app.updateBackupStatus(err)
+		}
+	}
+}
+
+// writes a verified, timestamped backup to config.BackupDir and prunes the old ones
+// returns the path of the new backup
+func rotating_backup_db(ctx context.Context, config Config, db *sql.DB) (string, error) {
+	storage := new_storage_backend(config.Datasource)
+	if err := os.MkdirAll(config.BackupDir, 0o755); err != nil {
+		return "", fmt.Errorf("failed to create backup dir: %w", err)
+	}
+
+	now := time.Now().UTC()
+	path := filepath.Join(config.BackupDir, backup_file_prefix+now.Format(backup_time_format)+storage.backupSuffix())
+	if err := backup_db_to_file(ctx, storage, db, path); err != nil {
+		return "", err
+	}
+
+	if err := storage.verifyBackup(ctx, path); err != nil {
+		remove_backup(path)
+		return "", err
+	}
+	slog.Info("verified database backup", "backup-path", path)
+
+	if err := prune_backups(config, now); err != nil {
+		return path, fmt.Errorf("failed to prune backups: %w", err)
+	}
+	return path, nil
+}
+
+// deletes the backups in config.BackupDir which are not kept by the retention policy
+func prune_backups(config Config, now time.Time) error {
+	backups, err := list_backups(config.BackupDir, new_storage_backend(config.Datasource).backupSuffix())
+	if err != nil {
+		return err
+	}
+
+	keep := backups_to_keep(backups, config.BackupKeepLast, config.BackupKeepDaily, config.BackupKeepWeekly)
+	for _, backup := range backups {
+		if !keep[backup.path] {
+			slog.Info("deleting old database backup", "backup-path", backup.path, "age", now.Sub(backup.time).Truncate(time.Second))
+			remove_backup(backup.path)
+		}
+	}
+	return nil
+}
+
+// keeps the newest keepLast backups and the newest backup of each of the
+// latest keepDaily days and keepWeekly weeks
+// backups must be sorted newest first
+func backups_to_keep(backups []backupFile, keepLast, keepDaily, keepWeekly int) map[string]bool {
+	keep := make(map[string]bool, len(backups))
+	for i := 0; i < keepLast && i < len(backups); i++ {
+		keep[backups[i].path] = true
+	}
+
+	keepNewestPer := func(n int, period func(time.Time) string) {
+		seen := map[string]bool{}
+		for _, backup := range backups {
+			key := period(backup.time)
+			if seen[key] {
+				continue
+			}
+			if len(seen) == n {
+				return
+			}
+			seen[key] = true
+			keep[backup.path] = true
+		}
+	}
+	keepNewestPer(keepDaily, func(t time.Time) string {
+		return t.Format(time.DateOnly)
+	})
+	keepNewestPer(keepWeekly, func(t time.Time) string {
+		year, week := t.ISOWeek()
+		return fmt.Sprintf("%d-%d", year, week)
+	})
+	return keep
+}
+
+// the backups in dir, newest first
+// files which don't look like backups or don't end in suffix are ignored
+func list_backups(dir, suffix string) ([]backupFile, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list backups: %w", err)
+	}
+
+	var backups []backupFile
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, backup_file_prefix) || !strings.HasSuffix(name, suffix) {
+			continue
+		}
+
+		t, err := time.Parse(backup_time_format, strings.TrimSuffix(strings.TrimPrefix(name, backup_file_prefix), suffix))
+		if err != nil {
+			continue
+		}
+		backups = append(backups, backupFile{path: filepath.Join(dir, name), time: t})
+	}
+
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].time.After(backups[j].time)
+	})
+	return backups, nil
+}
+
+// also removes the journal files sqlite might have left
+func remove_backup(path string) {
+	for _, file := range []string{path, path + "-wal", path + "-shm"} {
+		if err := os.Remove(file); err != nil && !errors.Is(err, os.ErrNotExist) {
+			slog.Warn("failed to delete database backup", "backup-path", file, "err", err)
+		}
+	}
+}
+
+// err is the result of the latest backup
+func (app *App) updateBackupStatus(err error) {
+	backups, listErr := list_backups(app.config.BackupDir, new_storage_backend(app.config.Datasource).backupSuffix())
+	if err == nil {
+		err = listErr
+	}
+
+	status := BackupStatus{Healthy: err == nil, Backups: len(backups)}
+	if len(backups) > 0 {
+		status.LastBackup = &backups[0].time
+	}
+	if err != nil {
+		errstr := err.Error()
+		status.Error = &errstr
+	}
+
+	app.backupMutex.Lock()
+	defer app.backupMutex.Unlock()
+	app.backupStatus = status
+}
+
+// a backup is overdue if the scheduled backup was missed twice
+func (app *App) getBackupStatus(now time.Time) BackupStatus {
+	app.backupMutex.Lock()
+	status := app.backupStatus
+	app.backupMutex.Unlock()
+
+	interval := app.config.BackupInterval
+	if status.Healthy && status.LastBackup != nil && now.Sub(*status.LastBackup) > 2*interval {
+		errstr := fmt.Sprintf("last backup is older than %s", 2*interval)
+		status.Healthy = false
+		status.Error = &errstr
+	}
+	return status
+}
@@ -0,0 +1,365 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/bafto/FindFavouriteSong/tournament"
+	"golang.org/x/term"
+)
+
+// the ffs subcommand runs a session in the terminal against /api/v1 of a running server
+// the server only knows users who logged in with spotify, so the client
+// reuses the session cookie of a browser in which the user is logged in
+
+const (
+	client_poll_interval = time.Second // how often the pair is polled while waiting for a group
+	client_usage         = `usage: FindFavouriteSong ffs [flags]
+
+Resumes the current session or starts a new one with -playlist.
+Keys: left/a/1 first song wins, right/d/2 second song wins, t tie, s skip, u undo, q quit
+
+flags:
+`
+)
+
+// the decisions which can be made on a pair
+const (
+	key_first = iota
+	key_second
+	key_tie
+	key_skip
+	key_undo
+	key_quit
+)
+
+type apiClient struct {
+	server   string
+	user     string
+	password string
+	cookie   string
+	http     *http.Client
+}
+
+// an error answered by the server
+type apiClientError struct {
+	APIError
+}
+
+func (err apiClientError) Error() string {
+	return fmt.Sprintf("%d: %s", err.Status, err.APIError.Error)
+}
+
+func runClient(args []string) error {
+	flags := flag.NewFlagSet("ffs", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprint(flags.Output(), client_usage)
+		flags.PrintDefaults()
+	}
+
+	client := &apiClient{http: &http.Client{Timeout: 30 * time.Second}}
+	flags.StringVar(&client.server, "server", envOrDefault("FFS_SERVER", "http://localhost:8080"), "url of the server, env FFS_SERVER")
+	flags.StringVar(&client.user, "user", os.Getenv("FFS_USER"), "basic auth user, env FFS_USER")
+	flags.StringVar(&client.password, "password", os.Getenv("FFS_PASSWORD"), "basic auth password, env FFS_PASSWORD")
+	flags.StringVar(&client.cookie, "cookie", os.Getenv("FFS_COOKIE"), "value of the "+session_name+" cookie of a browser which is logged in, env FFS_COOKIE")
+	playlist := flags.String("playlist", "", "url or id of a playlist to start a new session with")
+	mode := flags.String("mode", tournament.ModeKnockout, "mode of a new session: knockout, double_elimination, swiss or ranking")
+	sessionID := flags.Int64("session", 0, "id of an incomplete session to resume")
+	top := flags.Int("top", 10, "number of songs in the statistics table, 0 for all")
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	client.server = strings.TrimSuffix(client.server, "/")
+
+	session, err := client.startOrResumeSession(*playlist, *mode, *sessionID)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("session %d (%s)\n", session.ID, session.Mode)
+
+	winner, err := client.playSession(session.ID)
+	if err != nil || winner == nil {
+		return err
+	}
+
+	fmt.Printf("\nYour favourite song is %s by %s\n\n", winner.Title, winner.Artists)
+	return client.printStatistics(session.Playlist, *top)
+}
+
+// resumes the current session of the user if no playlist is given
+func (client *apiClient) startOrResumeSession(playlist, mode string, sessionID int64) (APISession, error) {
+	var user APIUser
+	if err := client.do(http.MethodGet, "/me", nil, &user); err != nil {
+		return APISession{}, err
+	}
+
+	if sessionID != 0 && (user.CurrentSession == nil || *user.CurrentSession != sessionID) {
+		if err := client.do(http.MethodPatch, "/me", APIUpdateUser{CurrentSession: &sessionID}, &user); err != nil {
+			return APISession{}, err
+		}
+	}
+
+	if playlist != "" {
+		if user.CurrentSession != nil {
+			return APISession{}, fmt.Errorf("session %d is not finished yet, resume it or leave it in the browser", *user.CurrentSession)
+		}
+
+		// urls are added, ids have to be added already
+		if strings.Contains(playlist, "/") {
+			var added APIPlaylist
+			if err := client.do(http.MethodPost, "/playlists", APINewPlaylist{Url: playlist}, &added); err != nil {
+				return APISession{}, err
+			}
+			playlist = added.ID
+		}
+
+		var session APISession
+		err := client.do(http.MethodPost, "/sessions", APINewSession{Playlist: playlist, Mode: mode}, &session)
+		return session, err
+	}
+
+	if user.CurrentSession == nil {
+		if err := client.printIncompleteSessions(); err != nil {
+			return APISession{}, err
+		}
+		return APISession{}, fmt.Errorf("no current session, resume one with -session or start one with -playlist")
+	}
+
+	var session APISession
+	err := client.do(http.MethodGet, "/sessions/"+strconv.FormatInt(*user.CurrentSession, 10), nil, &session)
+	return session, err
+}
+
+// lets the user decide pairs until the session has a winner
+// returns a nil winner if the user quit
+func (client *apiClient) playSession(sessionID int64) (*APIItem, error) {
+	sessionPath := "/sessions/" + strconv.FormatInt(sessionID, 10)
+
+	var state APISessionState
+	if err := client.do(http.MethodGet, sessionPath+"/pair", nil, &state); err != nil {
+		return nil, err
+	}
+
+	for state.Winner == nil {
+		printPair(state)
+
+		// the pair changes once the other members voted
+		if state.Group != nil && state.Group.Voted {
+			time.Sleep(client_poll_interval)
+			if err := client.do(http.MethodGet, sessionPath+"/pair", nil, &state); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		var err error
+		switch key, keyErr := readKey(); {
+		case keyErr != nil:
+			return nil, keyErr
+		case key == key_quit:
+			return nil, nil
+		case key == key_undo:
+			err = client.do(http.MethodDelete, sessionPath+"/matches/latest", nil, &state)
+		default:
+			err = client.do(http.MethodPost, sessionPath+"/matches", newAPIMatchFromKey(state, key), &state)
+		}
+
+		var apiErr apiClientError
+		if errors.As(err, &apiErr) && apiErr.Status == http.StatusBadRequest {
+			// e.g. nothing to undo
+			fmt.Println(apiErr.APIError.Error)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return state.Winner, nil
+}
+
+func newAPIMatchFromKey(state APISessionState, key int) APINewMatch {
+	first, second := state.Pair[0].ID, state.Pair[1].ID
+	switch key {
+	case key_second:
+		return APINewMatch{Winner: second, Loser: first, Outcome: tournament.OutcomeWin}
+	case key_tie:
+		return APINewMatch{Winner: first, Loser: second, Outcome: tournament.OutcomeTie}
+	case key_skip:
+		return APINewMatch{Winner: first, Loser: second, Outcome: tournament.OutcomeSkip}
+	default:
+		return APINewMatch{Winner: first, Loser: second, Outcome: tournament.OutcomeWin}
+	}
+}
+
+func printPair(state APISessionState) {
+	fmt.Printf("\nround %d, match %d\n", state.Round+1, state.Matches+1)
+	fmt.Printf("  <  %s - %s\n", state.Pair[0].Title, state.Pair[0].Artists)
+	fmt.Printf("  >  %s - %s\n", state.Pair[1].Title, state.Pair[1].Artists)
+	if state.Group != nil {
+		fmt.Printf("group %s: %d/%d voted\n", state.Group.InviteCode, state.Group.Votes, state.Group.Members)
+	}
+}
+
+// reads a single keypress, without waiting for enter if stdin is a terminal
+// otherwise a line is read, so the client can be scripted
+func readKey() (int, error) {
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return readKeyFromLine()
+	}
+
+	oldState, err := term.MakeRaw(fd)
+	if err != nil {
+		return 0, fmt.Errorf("could not read from terminal: %w", err)
+	}
+	defer term.Restore(fd, oldState)
+
+	buf := make([]byte, 3)
+	for {
+		n, err := os.Stdin.Read(buf)
+		if err != nil {
+			return 0, err
+		}
+
+		switch string(buf[:n]) {
+		case "\x1b[D", "a", "1":
+			return key_first, nil
+		case "\x1b[C", "d", "2":
+			return key_second, nil
+		case "t":
+			return key_tie, nil
+		case "s":
+			return key_skip, nil
+		case "u":
+			return key_undo, nil
+		case "q", "\x03", "\x04": // ctrl+c and ctrl+d don't send signals in raw mode
+			return key_quit, nil
+		}
+	}
+}
+
+var stdinLines = bufio.NewScanner(os.Stdin)
+
+func readKeyFromLine() (int, error) {
+	for stdinLines.Scan() {
+		switch strings.TrimSpace(stdinLines.Text()) {
+		case "a", "1":
+			return key_first, nil
+		case "d", "2":
+			return key_second, nil
+		case "t":
+			return key_tie, nil
+		case "s":
+			return key_skip, nil
+		case "u":
+			return key_undo, nil
+		case "q":
+			return key_quit, nil
+		}
+	}
+	if err := stdinLines.Err(); err != nil {
+		return 0, err
+	}
+	return key_quit, nil
+}
+
+func (client *apiClient) printIncompleteSessions() error {
+	var sessions []APISession
+	if err := client.do(http.MethodGet, "/sessions", nil, &sessions); err != nil {
+		return err
+	}
+
+	table := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(table, "SESSION\tPLAYLIST\tMODE\tSTARTED")
+	for _, session := range sessions {
+		if session.Winner == nil {
+			fmt.Fprintf(table, "%d\t%s\t%s\t%s\n", session.ID, session.Playlist, session.Mode, session.Created.Format(time.DateOnly))
+		}
+	}
+	return table.Flush()
+}
+
+// the points of the songs over all sessions of the user with the playlist
+func (client *apiClient) printStatistics(playlist string, top int) error {
+	var statistics []GetStatisticsJsonResult
+	if err := client.do(http.MethodGet, "/playlists/"+playlist+"/statistics", nil, &statistics); err != nil {
+		return err
+	}
+	sort.SliceStable(statistics, func(i, j int) bool {
+		return statistics[i].Points > statistics[j].Points
+	})
+	if top > 0 && top < len(statistics) {
+		statistics = statistics[:top]
+	}
+
+	table := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(table, "#\tPOINTS\tTITLE\tARTISTS")
+	for i, item := range statistics {
+		fmt.Fprintf(table, "%d\t%d\t%s\t%s\n", i+1, item.Points, item.Title, item.Artists)
+	}
+	return table.Flush()
+}
+
+// sends body as JSON to /api/v1 and decodes the answer into result
+func (client *apiClient) do(method, path string, body, result any) error {
+	var reqBody io.Reader
+	if body != nil {
+		encoded, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reqBody = bytes.NewReader(encoded)
+	}
+
+	req, err := http.NewRequest(method, client.server+"/api/v1"+path, reqBody)
+	if err != nil {
+		return err
+	}
+	req.SetBasicAuth(client.user, client.password)
+	req.AddCookie(&http.Cookie{Name: session_name, Value: client.cookie})
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := client.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("could not reach server: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		apiErr := apiClientError{APIError{Status: resp.StatusCode, Error: resp.Status}}
+		_ = json.NewDecoder(resp.Body).Decode(&apiErr.APIError)
+		return fmt.Errorf("%s %s: %w", method, path, apiErr)
+	}
+
+	if result == nil || resp.StatusCode == http.StatusNoContent {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+		return fmt.Errorf("could not decode answer of %s %s: %w", method, path, err)
+	}
+	return nil
+}
+
+func envOrDefault(key, def string) string {
+	if value, ok := os.LookupEnv(key); ok {
+		return value
+	}
+	return def
+}
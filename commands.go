@@ -0,0 +1,257 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"sort"
+	"strconv"
+)
+
+// the subcommands of the binary, without one the app is served
+// all but ffs read the config and work on config.Datasource
+// commands which don't need the config get an empty one
+
+var errUsage = errors.New("invalid arguments")
+
+type command struct {
+	usage       string
+	description string
+	needsConfig bool
+	run         func(config Config, args []string) error
+}
+
+var commands map[string]command
+
+// initialized in init, as usage refers to commands
+func init() {
+	commands = map[string]command{
+		"serve": {
+			description: "migrate the database and serve the app (default)",
+			needsConfig: true,
+			run:         serve,
+		},
+		"migrate": {
+			usage:       "up | down [steps] | version | force <version>",
+			description: "migrate the database, a backup is written to backup_path first",
+			needsConfig: true,
+			run:         migrateCommand,
+		},
+		"backup": {
+			usage:       "[path]",
+			description: "copy the database to path, or to a timestamped file in backup_dir which is then pruned",
+			needsConfig: true,
+			run:         backupCommand,
+		},
+		"restore": {
+			usage:       "path",
+			description: "overwrite the database with the backup at path, stop the server first",
+			needsConfig: true,
+			run:         restoreCommand,
+		},
+		"checkpoint": {
+			description: "write the WAL into the database file, or force a checkpoint in postgres",
+			needsConfig: true,
+			run:         checkpointCommand,
+		},
+		"vacuum": {
+			description: "rebuild the database to reclaim unused space",
+			needsConfig: true,
+			run:         vacuumCommand,
+		},
+		"ffs": {
+			usage:       "[flags]",
+			description: "run a session in the terminal against a running server, see ffs -h",
+			run: func(_ Config, args []string) error {
+				return runClient(args)
+			},
+		},
+	}
+}
+
+// returns the exit code
+func runCommand(args []string) int {
+	name := "serve"
+	if len(args) > 0 {
+		name, args = args[0], args[1:]
+	}
+
+	cmd, ok := commands[name]
+	if !ok {
+		usage()
+		return 2
+	}
+
+	var config Config
+	if cmd.needsConfig {
+		var err error
+		config, err = read_config()
+		if err != nil {
+			panic(err)
+		}
+		configure_logging(config.Log_level)
+	}
+
+	if err := cmd.run(config, args); err != nil {
+		if errors.Is(err, errUsage) {
+			fmt.Fprintf(os.Stderr, "usage: %s %s %s\n", os.Args[0], name, cmd.usage)
+			return 2
+		}
+		slog.Error("command failed", "command", name, "err", err)
+		return 1
+	}
+	return 0
+}
+
+func usage() {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Fprintf(os.Stderr, "usage: %s [command]\n\ncommands:\n", os.Args[0])
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "  %s %s\n    \t%s\n", name, commands[name].usage, commands[name].description)
+	}
+}
+
+func migrateCommand(config Config, args []string) error {
+	if len(args) == 0 || len(args) > 2 || (len(args) == 2 && args[0] != "down" && args[0] != "force") {
+		return errUsage
+	}
+
+	ctx := context.Background()
+	storage := new_storage_backend(config.Datasource)
+	db, err := create_db(ctx, config.Datasource)
+	if err != nil {
+		return fmt.Errorf("Error opening DB connection: %w", err)
+	}
+	defer db.Close()
+
+	switch args[0] {
+	case "up":
+		return migrate_db(ctx, config, db)
+	case "down":
+		steps := 1
+		if len(args) == 2 {
+			if steps, err = strconv.Atoi(args[1]); err != nil || steps <= 0 {
+				return errUsage
+			}
+		}
+		return migrate_db_down(ctx, config, db, steps)
+	case "force":
+		if len(args) != 2 {
+			return errUsage
+		}
+		version, err := strconv.Atoi(args[1])
+		if err != nil || version < 0 {
+			return errUsage
+		}
+		return migrate_db_force(storage, db, version)
+	case "version":
+		version, dirty, err := db_version(storage, db)
+		if err != nil {
+			return fmt.Errorf("failed to read db version: %w", err)
+		}
+		fmt.Printf("version %d, dirty %t\n", version, dirty)
+		return nil
+	default:
+		return errUsage
+	}
+}
+
+func backupCommand(config Config, args []string) error {
+	if len(args) > 1 || (len(args) == 0 && config.BackupDir == "") {
+		return errUsage
+	}
+
+	ctx := context.Background()
+	storage := new_storage_backend(config.Datasource)
+	db, err := create_db(ctx, config.Datasource)
+	if err != nil {
+		return fmt.Errorf("Error opening DB connection: %w", err)
+	}
+	defer db.Close()
+
+	if len(args) == 0 {
+		path, err := rotating_backup_db(ctx, config, db)
+		if path != "" {
+			fmt.Println(path)
+		}
+		return err
+	}
+
+	if err := backup_db_to_file(ctx, storage, db, args[0]); err != nil {
+		return err
+	}
+	return storage.verifyBackup(ctx, args[0])
+}
+
+func restoreCommand(config Config, args []string) error {
+	if len(args) != 1 {
+		return errUsage
+	}
+	path := args[0]
+	ctx := context.Background()
+	storage := new_storage_backend(config.Datasource)
+
+	// sqlite would create an empty database and restore that
+	if _, err := os.Stat(path); err != nil {
+		return fmt.Errorf("backup not found: %w", err)
+	}
+
+	if err := storage.verifyBackup(ctx, path); err != nil {
+		return err
+	}
+
+	db, err := create_db(ctx, config.Datasource)
+	if err != nil {
+		return fmt.Errorf("Error opening DB connection: %w", err)
+	}
+	defer db.Close()
+
+	return restore_db_from_file(ctx, storage, db, path)
+}
+
+func checkpointCommand(config Config, args []string) error {
+	if len(args) != 0 {
+		return errUsage
+	}
+
+	ctx := context.Background()
+	db, err := create_db(ctx, config.Datasource)
+	if err != nil {
+		return fmt.Errorf("Error opening DB connection: %w", err)
+	}
+	defer db.Close()
+
+	slog.Info("starting db checkpoint")
+	if err := new_storage_backend(config.Datasource).checkpoint(ctx, db); err != nil {
+		return fmt.Errorf("failed to checkpoint db: %w", err)
+	}
+	slog.Info("done checkpointing db")
+	return nil
+}
+
+func vacuumCommand(config Config, args []string) error {
+	if len(args) != 0 {
+		return errUsage
+	}
+
+	ctx := context.Background()
+	db, err := create_db(ctx, config.Datasource)
+	if err != nil {
+		return fmt.Errorf("Error opening DB connection: %w", err)
+	}
+	defer db.Close()
+
+	slog.Info("starting db vacuum")
+	if err := new_storage_backend(config.Datasource).vacuum(ctx, db); err != nil {
+		return fmt.Errorf("failed to vacuum db: %w", err)
+	}
+	slog.Info("done vacuuming db")
+	return nil
+}
@@ -1,39 +1,70 @@
 package main
 
 import (
+	"encoding/base64"
+	"fmt"
 	"log/slog"
 	"strings"
 	"time"
 
+	"github.com/gorilla/securecookie"
 	"github.com/spf13/viper"
 )
 
 type Config struct {
 	Spotify_client_id     string            `mapstructure:"spotify_client_id"`
 	Spotify_client_secret string            `mapstructure:"spotify_client_secret"`
-	Datasource            string            `mapstructure:"data_source"`
-	BackupPath            string            `mapstructure:"backup_path"`
+	Spotify_accounts_url  string            `mapstructure:"spotify_accounts_url"`
+	Spotify_api_url       string            `mapstructure:"spotify_api_url"`
+	Datasource            string            `mapstructure:"data_source"` // sqlite data source or postgres:// url
+	BackupPath            string            `mapstructure:"backup_path"` // backup before migrations
+	BackupDir             string            `mapstructure:"backup_dir"`  // scheduled backups, empty to disable them
+	BackupInterval        time.Duration     `mapstructure:"backup_interval"`
+	BackupKeepLast        int               `mapstructure:"backup_keep_last"`
+	BackupKeepDaily       int               `mapstructure:"backup_keep_daily"`
+	BackupKeepWeekly      int               `mapstructure:"backup_keep_weekly"`
 	Port                  string            `mapstructure:"port"`
 	Log_level             string            `mapstructure:"log_level"`
 	Redirect_url          string            `mapstructure:"redirect_url"`
+	LoginTimeout          time.Duration     `mapstructure:"login_timeout"` // time to finish the spotify login
 	Shutdown_timeout      time.Duration     `mapstructure:"shutdown_timeout"`
 	Users                 map[string]string `mapstructure:"users"`
+	Admins                []string          `mapstructure:"admins"`             // spotify user ids allowed to use /api/admin
+	GroupVoteTimeout      time.Duration     `mapstructure:"group_vote_timeout"` // default for new group sessions
 	CheckpointInterval    time.Duration     `mapstructure:"checkpoint_interval"`
 	CheckpointTimeout     time.Duration     `mapstructure:"checkpoint_timeout"`
+	CookieAuthKey         string            `mapstructure:"cookie_auth_key"`       // base64 encoded, 32 or 64 bytes
+	CookieEncryptionKey   string            `mapstructure:"cookie_encryption_key"` // base64 encoded, 16, 24 or 32 bytes
+	PlaylistDir           string            `mapstructure:"playlist_dir"`          // playlist files for file: urls, empty to disable them
+	FeedHosts             []string          `mapstructure:"feed_hosts"`            // hosts of JSON feeds, empty to disable them
 }
 
 func read_config() (Config, error) {
 	viper.SetDefault("spotify_client_id", "")
 	viper.SetDefault("spotify_client_secret", "")
-	viper.SetDefault("data_source", "file:ffs.db?_journal_mode=WAL")
+	viper.SetDefault("spotify_accounts_url", "https://accounts.spotify.com")
+	viper.SetDefault("spotify_api_url", "https://api.spotify.com/v1")
+	viper.SetDefault("data_source", default_sqlite_data_source)
 	viper.SetDefault("backup_path", "ffs.backup.db")
+	viper.SetDefault("backup_dir", "") // scheduled backups are opt-in
+	viper.SetDefault("backup_interval", 6*time.Hour)
+	viper.SetDefault("backup_keep_last", 4)
+	viper.SetDefault("backup_keep_daily", 7)
+	viper.SetDefault("backup_keep_weekly", 4)
 	viper.SetDefault("port", "8080")
 	viper.SetDefault("log_level", "INFO")
 	viper.SetDefault("redirect_url", "http://localhost:8080/spotifyauthentication")
+	viper.SetDefault("login_timeout", 10*time.Minute)
 	viper.SetDefault("shutdown_timeout", time.Second*10)
 	viper.SetDefault("users", map[string]string{})
+	viper.SetDefault("admins", []string{})
+	viper.SetDefault("group_vote_timeout", time.Minute)
 	viper.SetDefault("checkpoint_interval", 2*time.Hour)
 	viper.SetDefault("checkpoint_timeout", 1*time.Minute)
+	viper.SetDefault("cookie_auth_key", "")
+	viper.SetDefault("cookie_encryption_key", "")
+	viper.SetDefault("playlist_dir", "")
+	viper.SetDefault("feed_hosts", []string{})
 
 	viper.SetEnvPrefix("FFS")
 	viper.AutomaticEnv()
@@ -74,3 +105,36 @@ func parseCommaSeparatedMap(input string) map[string]string {
 	}
 	return result
 }
+
+// returns the keys used to sign and encrypt the session cookie
+// if a key is not configured a random one is generated, which means
+// sessions won't survive a restart
+func read_cookie_keys(config Config) (authKey, encryptionKey []byte, err error) {
+	authKey, err = decode_cookie_key("cookie_auth_key", config.CookieAuthKey, 64)
+	if err != nil {
+		return nil, nil, err
+	}
+	encryptionKey, err = decode_cookie_key("cookie_encryption_key", config.CookieEncryptionKey, 32)
+	if err != nil {
+		return nil, nil, err
+	}
+	switch len(encryptionKey) {
+	case 16, 24, 32:
+	default:
+		return nil, nil, fmt.Errorf("cookie_encryption_key must be 16, 24 or 32 bytes long, got %d", len(encryptionKey))
+	}
+	return authKey, encryptionKey, nil
+}
+
+func decode_cookie_key(name, encoded string, randomLength int) ([]byte, error) {
+	if encoded == "" {
+		slog.Warn("no cookie key configured, generating a random one. Sessions will not survive a restart", "key", name)
+		return securecookie.GenerateRandomKey(randomLength), nil
+	}
+
+	key, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("%s is not valid base64: %w", name, err)
+	}
+	return key, nil
+}
@@ -8,21 +8,20 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"os"
 
 	"github.com/golang-migrate/migrate/v4"
-	"github.com/golang-migrate/migrate/v4/database/sqlite3"
 	"github.com/golang-migrate/migrate/v4/source/iofs"
-	sqlite3_driver "github.com/mattn/go-sqlite3"
 )
 
 //go:generate sqlc generate
 
-//go:embed sql/migrations/*.sql
+//go:embed sql/migrations/*.sql sql/postgres/migrations/*.sql
 var migrations embed.FS
 
-// opens the database connection
+// opens the database connection with the driver of the backend of dsn
 func create_db(ctx context.Context, dsn string) (*sql.DB, error) {
-	db, err := sql.Open("sqlite3", dsn)
+	db, err := sql.Open(new_storage_backend(dsn).driverName(), dsn)
 	if err != nil {
 		return nil, err
 	}
@@ -30,94 +29,142 @@ func create_db(ctx context.Context, dsn string) (*sql.DB, error) {
 	return db, db.Ping()
 }
 
-func migrate_db(ctx context.Context, db *sql.DB) error {
-	slog.Info("creating database backup before migration", "backup-path", config.BackupPath)
-	// backupDest
-	backupDest, err := create_db(ctx, config.BackupPath)
+// the database is backed up to config.BackupPath first and restored from there if a migration fails
+func migrate_db(ctx context.Context, config Config, db *sql.DB) error {
+	storage := new_storage_backend(config.Datasource)
+	m, err := new_migration(storage, db)
 	if err != nil {
-		return fmt.Errorf("failed to create backup db: %w", err)
+		return err
+	}
+	// checked before the backup, which would otherwise overwrite the last good one
+	if err := check_not_dirty(m); err != nil {
+		return err
 	}
-	defer backupDest.Close()
 
-	if err := backup_db(ctx, backupDest, db); err != nil {
-		return fmt.Errorf("failed to backup db: %w", err)
+	if err := backup_db_to_file(ctx, storage, db, config.BackupPath); err != nil {
+		return err
 	}
-	slog.Info("done creating database backup")
 
-	// create driver and source
-	driver, err := sqlite3.WithInstance(db, &sqlite3.Config{})
-	if err != nil {
-		return fmt.Errorf("failed to create migration driver: %w", err)
+	// execute migrations
+	if err := m.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		return restore_failed_migration(ctx, config, db, fmt.Errorf("failed to migrate db to new version: %w", err))
 	}
-	source, err := iofs.New(migrations, "sql/migrations")
+	v, d, err := m.Version()
+	slog.Info("Migrated db", "version", v, "dirty", d, "err", err)
+	return nil
+}
+
+// rolls back the latest steps migrations, like migrate_db with a backup before
+func migrate_db_down(ctx context.Context, config Config, db *sql.DB, steps int) error {
+	storage := new_storage_backend(config.Datasource)
+	m, err := new_migration(storage, db)
 	if err != nil {
-		return fmt.Errorf("failed to create migration source: %w", err)
+		return err
+	}
+	if err := check_not_dirty(m); err != nil {
+		return err
 	}
 
-	// create migration instance
-	m, err := migrate.NewWithInstance("iofs", source, "sqlite3", driver)
-	if err != nil {
-		return fmt.Errorf("failed to create migration: %w", err)
+	if err := backup_db_to_file(ctx, storage, db, config.BackupPath); err != nil {
+		return err
 	}
 
-	// execute migrations
-	if err := m.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
-		return fmt.Errorf("failed to migrate db to new version: %w", err)
+	if err := m.Steps(-steps); err != nil {
+		return restore_failed_migration(ctx, config, db, fmt.Errorf("failed to migrate db down: %w", err))
 	}
 	v, d, err := m.Version()
-	slog.Info("Migrated db", "version", v, "dirty", d, "err", err)
+	slog.Info("Migrated db down", "version", v, "dirty", d, "err", err)
+	return nil
+}
+
+// marks the schema as being at version without running any migrations
+// to be used after repairing a dirty database by hand
+func migrate_db_force(storage storageBackend, db *sql.DB, version int) error {
+	m, err := new_migration(storage, db)
+	if err != nil {
+		return err
+	}
+
+	if err := m.Force(version); err != nil {
+		return fmt.Errorf("failed to force db version: %w", err)
+	}
+	slog.Info("Forced db version", "version", version)
+	return nil
+}
+
+// a dirty database was left behind by a failed migration which could not be restored
+func check_not_dirty(m *migrate.Migrate) error {
+	v, dirty, err := m.Version()
+	if err != nil && !errors.Is(err, migrate.ErrNilVersion) {
+		return fmt.Errorf("failed to read db version: %w", err)
+	}
+	if dirty {
+		return fmt.Errorf("db is dirty at version %d, restore a backup with '%s restore <path>'"+
+			" or repair the schema by hand and run '%s migrate force <version>'", v, os.Args[0], os.Args[0])
+	}
 	return nil
 }
 
-func backup_db(ctx context.Context, dest, src *sql.DB) error {
-	destConn, err := dest.Conn(ctx)
+// restores db from the backup migrate_db took and returns migrationErr
+func restore_failed_migration(ctx context.Context, config Config, db *sql.DB, migrationErr error) error {
+	slog.Error("migration failed, restoring database backup", "backup-path", config.BackupPath, "err", migrationErr)
+
+	if err := restore_db_from_file(ctx, new_storage_backend(config.Datasource), db, config.BackupPath); err != nil {
+		return errors.Join(migrationErr, err)
+	}
+	return migrationErr
+}
+
+// the version of the schema and whether the last migration failed
+func db_version(storage storageBackend, db *sql.DB) (uint, bool, error) {
+	m, err := new_migration(storage, db)
+	if err != nil {
+		return 0, false, err
+	}
+
+	v, d, err := m.Version()
+	if errors.Is(err, migrate.ErrNilVersion) {
+		return 0, false, nil
+	}
+	return v, d, err
+}
+
+// the returned migration must not be closed, as that would close db
+func new_migration(storage storageBackend, db *sql.DB) (*migrate.Migrate, error) {
+	// create driver and source
+	driver, err := storage.migrationDriver(db)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create migration driver: %w", err)
+	}
+	source, err := iofs.New(migrations, storage.migrationsDir())
 	if err != nil {
-		return fmt.Errorf("failed to establish connection to backup destination db: %w", err)
+		return nil, fmt.Errorf("failed to create migration source: %w", err)
 	}
-	defer destConn.Close()
 
-	srcConn, err := src.Conn(ctx)
+	// create migration instance
+	m, err := migrate.NewWithInstance("iofs", source, storage.driverName(), driver)
 	if err != nil {
-		return fmt.Errorf("failed to establish connection to backup source db: %w", err)
-	}
-	defer srcConn.Close()
-
-	return destConn.Raw(func(destConn any) error {
-		return srcConn.Raw(func(srcConn any) error {
-			destSQLiteConn, ok := destConn.(*sqlite3_driver.SQLiteConn)
-			if !ok {
-				return fmt.Errorf("can't convert destination connection to SQLiteConn")
-			}
-
-			srcSQLiteConn, ok := srcConn.(*sqlite3_driver.SQLiteConn)
-			if !ok {
-				return fmt.Errorf("can't convert source connection to SQLiteConn")
-			}
-
-			b, err := destSQLiteConn.Backup("main", srcSQLiteConn, "main")
-			if err != nil {
-				return fmt.Errorf("error initializing SQLite backup: %w", err)
-			}
-
-			done, err := b.Step(-1)
-			if !done {
-				return fmt.Errorf("step of -1, but not done")
-			}
-			if err != nil {
-				return fmt.Errorf("error in stepping backup: %w", err)
-			}
-
-			err = b.Finish()
-			if err != nil {
-				return fmt.Errorf("error finishing backup: %w", err)
-			}
-
-			return err
-		})
-	})
+		return nil, fmt.Errorf("failed to create migration: %w", err)
+	}
+	return m, nil
 }
 
-func checkpoint_db(ctx context.Context, db *sql.DB) error {
-	_, err := db.ExecContext(ctx, "PRAGMA WAL_CHECKPOINT(TRUNCATE)")
-	return err
+// creates or overwrites the backup at path with the contents of db
+func backup_db_to_file(ctx context.Context, storage storageBackend, db *sql.DB, path string) error {
+	slog.Info("creating database backup", "backup-path", path)
+	if err := storage.backupToFile(ctx, db, path); err != nil {
+		return err
+	}
+	slog.Info("done creating database backup")
+	return nil
+}
+
+// overwrites db with the backup at path
+func restore_db_from_file(ctx context.Context, storage storageBackend, db *sql.DB, path string) error {
+	slog.Info("restoring database backup", "backup-path", path)
+	if err := storage.restoreFromFile(ctx, db, path); err != nil {
+		return err
+	}
+	slog.Info("done restoring database backup")
+	return nil
 }
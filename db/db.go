@@ -24,21 +24,39 @@ func New(db DBTX) *Queries {
 func Prepare(ctx context.Context, db DBTX) (*Queries, error) {
 	q := Queries{db: db}
 	var err error
+	if q.addGroupMemberStmt, err = db.PrepareContext(ctx, addGroupMember); err != nil {
+		return nil, fmt.Errorf("error preparing query AddGroupMember: %w", err)
+	}
+	if q.addGroupSessionStmt, err = db.PrepareContext(ctx, addGroupSession); err != nil {
+		return nil, fmt.Errorf("error preparing query AddGroupSession: %w", err)
+	}
 	if q.addMatchStmt, err = db.PrepareContext(ctx, addMatch); err != nil {
 		return nil, fmt.Errorf("error preparing query AddMatch: %w", err)
 	}
+	if q.addOrUpdateGroupVoteStmt, err = db.PrepareContext(ctx, addOrUpdateGroupVote); err != nil {
+		return nil, fmt.Errorf("error preparing query AddOrUpdateGroupVote: %w", err)
+	}
 	if q.addOrUpdatePlaylistStmt, err = db.PrepareContext(ctx, addOrUpdatePlaylist); err != nil {
 		return nil, fmt.Errorf("error preparing query AddOrUpdatePlaylist: %w", err)
 	}
 	if q.addOrUpdatePlaylistItemStmt, err = db.PrepareContext(ctx, addOrUpdatePlaylistItem); err != nil {
 		return nil, fmt.Errorf("error preparing query AddOrUpdatePlaylistItem: %w", err)
 	}
+	if q.addOrUpdateRankingItemStmt, err = db.PrepareContext(ctx, addOrUpdateRankingItem); err != nil {
+		return nil, fmt.Errorf("error preparing query AddOrUpdateRankingItem: %w", err)
+	}
+	if q.addOrUpdateRatingStmt, err = db.PrepareContext(ctx, addOrUpdateRating); err != nil {
+		return nil, fmt.Errorf("error preparing query AddOrUpdateRating: %w", err)
+	}
 	if q.addPlaylistAddedByUserStmt, err = db.PrepareContext(ctx, addPlaylistAddedByUser); err != nil {
 		return nil, fmt.Errorf("error preparing query AddPlaylistAddedByUser: %w", err)
 	}
 	if q.addPlaylistItemBelongsToPlaylistStmt, err = db.PrepareContext(ctx, addPlaylistItemBelongsToPlaylist); err != nil {
 		return nil, fmt.Errorf("error preparing query AddPlaylistItemBelongsToPlaylist: %w", err)
 	}
+	if q.addPlaylistItemIfAbsentStmt, err = db.PrepareContext(ctx, addPlaylistItemIfAbsent); err != nil {
+		return nil, fmt.Errorf("error preparing query AddPlaylistItemIfAbsent: %w", err)
+	}
 	if q.addSessionStmt, err = db.PrepareContext(ctx, addSession); err != nil {
 		return nil, fmt.Errorf("error preparing query AddSession: %w", err)
 	}
@@ -48,9 +66,18 @@ func Prepare(ctx context.Context, db DBTX) (*Queries, error) {
 	if q.countMatchesForRoundStmt, err = db.PrepareContext(ctx, countMatchesForRound); err != nil {
 		return nil, fmt.Errorf("error preparing query CountMatchesForRound: %w", err)
 	}
+	if q.countPossibleNextItemsForSessionStmt, err = db.PrepareContext(ctx, countPossibleNextItemsForSession); err != nil {
+		return nil, fmt.Errorf("error preparing query CountPossibleNextItemsForSession: %w", err)
+	}
+	if q.deleteGroupVotesStmt, err = db.PrepareContext(ctx, deleteGroupVotes); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteGroupVotes: %w", err)
+	}
 	if q.deleteItemFromPlaylistStmt, err = db.PrepareContext(ctx, deleteItemFromPlaylist); err != nil {
 		return nil, fmt.Errorf("error preparing query DeleteItemFromPlaylist: %w", err)
 	}
+	if q.deleteMatchStmt, err = db.PrepareContext(ctx, deleteMatch); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteMatch: %w", err)
+	}
 	if q.deleteMatchesForSessionStmt, err = db.PrepareContext(ctx, deleteMatchesForSession); err != nil {
 		return nil, fmt.Errorf("error preparing query DeleteMatchesForSession: %w", err)
 	}
@@ -60,17 +87,38 @@ func Prepare(ctx context.Context, db DBTX) (*Queries, error) {
 	if q.deleteSessionStmt, err = db.PrepareContext(ctx, deleteSession); err != nil {
 		return nil, fmt.Errorf("error preparing query DeleteSession: %w", err)
 	}
+	if q.eliminateItemsWithLossesStmt, err = db.PrepareContext(ctx, eliminateItemsWithLosses); err != nil {
+		return nil, fmt.Errorf("error preparing query EliminateItemsWithLosses: %w", err)
+	}
+	if q.getActiveGroupMembersStmt, err = db.PrepareContext(ctx, getActiveGroupMembers); err != nil {
+		return nil, fmt.Errorf("error preparing query GetActiveGroupMembers: %w", err)
+	}
+	if q.getAllSessionsStmt, err = db.PrepareContext(ctx, getAllSessions); err != nil {
+		return nil, fmt.Errorf("error preparing query GetAllSessions: %w", err)
+	}
 	if q.getAllWinnersForUserStmt, err = db.PrepareContext(ctx, getAllWinnersForUser); err != nil {
 		return nil, fmt.Errorf("error preparing query GetAllWinnersForUser: %w", err)
 	}
 	if q.getCurrentRoundStmt, err = db.PrepareContext(ctx, getCurrentRound); err != nil {
 		return nil, fmt.Errorf("error preparing query GetCurrentRound: %w", err)
 	}
+	if q.getGroupSessionStmt, err = db.PrepareContext(ctx, getGroupSession); err != nil {
+		return nil, fmt.Errorf("error preparing query GetGroupSession: %w", err)
+	}
+	if q.getGroupSessionByInviteCodeStmt, err = db.PrepareContext(ctx, getGroupSessionByInviteCode); err != nil {
+		return nil, fmt.Errorf("error preparing query GetGroupSessionByInviteCode: %w", err)
+	}
+	if q.getGroupVotesStmt, err = db.PrepareContext(ctx, getGroupVotes); err != nil {
+		return nil, fmt.Errorf("error preparing query GetGroupVotes: %w", err)
+	}
 	if q.getItemIdsForPlaylistStmt, err = db.PrepareContext(ctx, getItemIdsForPlaylist); err != nil {
 		return nil, fmt.Errorf("error preparing query GetItemIdsForPlaylist: %w", err)
 	}
-	if q.getNextPairStmt, err = db.PrepareContext(ctx, getNextPair); err != nil {
-		return nil, fmt.Errorf("error preparing query GetNextPair: %w", err)
+	if q.getLatestMatchForSessionStmt, err = db.PrepareContext(ctx, getLatestMatchForSession); err != nil {
+		return nil, fmt.Errorf("error preparing query GetLatestMatchForSession: %w", err)
+	}
+	if q.getMatchesForSessionStmt, err = db.PrepareContext(ctx, getMatchesForSession); err != nil {
+		return nil, fmt.Errorf("error preparing query GetMatchesForSession: %w", err)
 	}
 	if q.getNonActiveUserSessionsStmt, err = db.PrepareContext(ctx, getNonActiveUserSessions); err != nil {
 		return nil, fmt.Errorf("error preparing query GetNonActiveUserSessions: %w", err)
@@ -87,9 +135,27 @@ func Prepare(ctx context.Context, db DBTX) (*Queries, error) {
 	if q.getPlaylistsForUserStmt, err = db.PrepareContext(ctx, getPlaylistsForUser); err != nil {
 		return nil, fmt.Errorf("error preparing query GetPlaylistsForUser: %w", err)
 	}
+	if q.getRankingForSessionStmt, err = db.PrepareContext(ctx, getRankingForSession); err != nil {
+		return nil, fmt.Errorf("error preparing query GetRankingForSession: %w", err)
+	}
+	if q.getRatingStmt, err = db.PrepareContext(ctx, getRating); err != nil {
+		return nil, fmt.Errorf("error preparing query GetRating: %w", err)
+	}
+	if q.getRatingLeaderboardStmt, err = db.PrepareContext(ctx, getRatingLeaderboard); err != nil {
+		return nil, fmt.Errorf("error preparing query GetRatingLeaderboard: %w", err)
+	}
+	if q.getRemainingItemsStmt, err = db.PrepareContext(ctx, getRemainingItems); err != nil {
+		return nil, fmt.Errorf("error preparing query GetRemainingItems: %w", err)
+	}
 	if q.getSessionStmt, err = db.PrepareContext(ctx, getSession); err != nil {
 		return nil, fmt.Errorf("error preparing query GetSession: %w", err)
 	}
+	if q.getSessionsForUserStmt, err = db.PrepareContext(ctx, getSessionsForUser); err != nil {
+		return nil, fmt.Errorf("error preparing query GetSessionsForUser: %w", err)
+	}
+	if q.getSpotifyTokenStmt, err = db.PrepareContext(ctx, getSpotifyToken); err != nil {
+		return nil, fmt.Errorf("error preparing query GetSpotifyToken: %w", err)
+	}
 	if q.getStatistics1Stmt, err = db.PrepareContext(ctx, getStatistics1); err != nil {
 		return nil, fmt.Errorf("error preparing query GetStatistics1: %w", err)
 	}
@@ -102,9 +168,27 @@ func Prepare(ctx context.Context, db DBTX) (*Queries, error) {
 	if q.initializePossibleNextItemsForSessionStmt, err = db.PrepareContext(ctx, initializePossibleNextItemsForSession); err != nil {
 		return nil, fmt.Errorf("error preparing query InitializePossibleNextItemsForSession: %w", err)
 	}
+	if q.resetCurrentSessionForGroupMembersStmt, err = db.PrepareContext(ctx, resetCurrentSessionForGroupMembers); err != nil {
+		return nil, fmt.Errorf("error preparing query ResetCurrentSessionForGroupMembers: %w", err)
+	}
+	if q.resetPossibleNextItemsForSessionStmt, err = db.PrepareContext(ctx, resetPossibleNextItemsForSession); err != nil {
+		return nil, fmt.Errorf("error preparing query ResetPossibleNextItemsForSession: %w", err)
+	}
+	if q.reviveItemsWithFewestLossesStmt, err = db.PrepareContext(ctx, reviveItemsWithFewestLosses); err != nil {
+		return nil, fmt.Errorf("error preparing query ReviveItemsWithFewestLosses: %w", err)
+	}
 	if q.setCurrentRoundStmt, err = db.PrepareContext(ctx, setCurrentRound); err != nil {
 		return nil, fmt.Errorf("error preparing query SetCurrentRound: %w", err)
 	}
+	if q.setGroupPairStmt, err = db.PrepareContext(ctx, setGroupPair); err != nil {
+		return nil, fmt.Errorf("error preparing query SetGroupPair: %w", err)
+	}
+	if q.setSeedStmt, err = db.PrepareContext(ctx, setSeed); err != nil {
+		return nil, fmt.Errorf("error preparing query SetSeed: %w", err)
+	}
+	if q.setSpotifyTokenStmt, err = db.PrepareContext(ctx, setSpotifyToken); err != nil {
+		return nil, fmt.Errorf("error preparing query SetSpotifyToken: %w", err)
+	}
 	if q.setUserSessionStmt, err = db.PrepareContext(ctx, setUserSession); err != nil {
 		return nil, fmt.Errorf("error preparing query SetUserSession: %w", err)
 	}
@@ -116,11 +200,26 @@ func Prepare(ctx context.Context, db DBTX) (*Queries, error) {
 
 func (q *Queries) Close() error {
 	var err error
+	if q.addGroupMemberStmt != nil {
+		if cerr := q.addGroupMemberStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing addGroupMemberStmt: %w", cerr)
+		}
+	}
+	if q.addGroupSessionStmt != nil {
+		if cerr := q.addGroupSessionStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing addGroupSessionStmt: %w", cerr)
+		}
+	}
 	if q.addMatchStmt != nil {
 		if cerr := q.addMatchStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing addMatchStmt: %w", cerr)
 		}
 	}
+	if q.addOrUpdateGroupVoteStmt != nil {
+		if cerr := q.addOrUpdateGroupVoteStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing addOrUpdateGroupVoteStmt: %w", cerr)
+		}
+	}
 	if q.addOrUpdatePlaylistStmt != nil {
 		if cerr := q.addOrUpdatePlaylistStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing addOrUpdatePlaylistStmt: %w", cerr)
@@ -131,6 +230,16 @@ func (q *Queries) Close() error {
 			err = fmt.Errorf("error closing addOrUpdatePlaylistItemStmt: %w", cerr)
 		}
 	}
+	if q.addOrUpdateRankingItemStmt != nil {
+		if cerr := q.addOrUpdateRankingItemStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing addOrUpdateRankingItemStmt: %w", cerr)
+		}
+	}
+	if q.addOrUpdateRatingStmt != nil {
+		if cerr := q.addOrUpdateRatingStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing addOrUpdateRatingStmt: %w", cerr)
+		}
+	}
 	if q.addPlaylistAddedByUserStmt != nil {
 		if cerr := q.addPlaylistAddedByUserStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing addPlaylistAddedByUserStmt: %w", cerr)
@@ -141,6 +250,11 @@ func (q *Queries) Close() error {
 			err = fmt.Errorf("error closing addPlaylistItemBelongsToPlaylistStmt: %w", cerr)
 		}
 	}
+	if q.addPlaylistItemIfAbsentStmt != nil {
+		if cerr := q.addPlaylistItemIfAbsentStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing addPlaylistItemIfAbsentStmt: %w", cerr)
+		}
+	}
 	if q.addSessionStmt != nil {
 		if cerr := q.addSessionStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing addSessionStmt: %w", cerr)
@@ -156,11 +270,26 @@ func (q *Queries) Close() error {
 			err = fmt.Errorf("error closing countMatchesForRoundStmt: %w", cerr)
 		}
 	}
+	if q.countPossibleNextItemsForSessionStmt != nil {
+		if cerr := q.countPossibleNextItemsForSessionStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing countPossibleNextItemsForSessionStmt: %w", cerr)
+		}
+	}
+	if q.deleteGroupVotesStmt != nil {
+		if cerr := q.deleteGroupVotesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteGroupVotesStmt: %w", cerr)
+		}
+	}
 	if q.deleteItemFromPlaylistStmt != nil {
 		if cerr := q.deleteItemFromPlaylistStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing deleteItemFromPlaylistStmt: %w", cerr)
 		}
 	}
+	if q.deleteMatchStmt != nil {
+		if cerr := q.deleteMatchStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteMatchStmt: %w", cerr)
+		}
+	}
 	if q.deleteMatchesForSessionStmt != nil {
 		if cerr := q.deleteMatchesForSessionStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing deleteMatchesForSessionStmt: %w", cerr)
@@ -176,6 +305,21 @@ func (q *Queries) Close() error {
 			err = fmt.Errorf("error closing deleteSessionStmt: %w", cerr)
 		}
 	}
+	if q.eliminateItemsWithLossesStmt != nil {
+		if cerr := q.eliminateItemsWithLossesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing eliminateItemsWithLossesStmt: %w", cerr)
+		}
+	}
+	if q.getActiveGroupMembersStmt != nil {
+		if cerr := q.getActiveGroupMembersStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getActiveGroupMembersStmt: %w", cerr)
+		}
+	}
+	if q.getAllSessionsStmt != nil {
+		if cerr := q.getAllSessionsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getAllSessionsStmt: %w", cerr)
+		}
+	}
 	if q.getAllWinnersForUserStmt != nil {
 		if cerr := q.getAllWinnersForUserStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing getAllWinnersForUserStmt: %w", cerr)
@@ -186,14 +330,34 @@ func (q *Queries) Close() error {
 			err = fmt.Errorf("error closing getCurrentRoundStmt: %w", cerr)
 		}
 	}
+	if q.getGroupSessionStmt != nil {
+		if cerr := q.getGroupSessionStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getGroupSessionStmt: %w", cerr)
+		}
+	}
+	if q.getGroupSessionByInviteCodeStmt != nil {
+		if cerr := q.getGroupSessionByInviteCodeStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getGroupSessionByInviteCodeStmt: %w", cerr)
+		}
+	}
+	if q.getGroupVotesStmt != nil {
+		if cerr := q.getGroupVotesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getGroupVotesStmt: %w", cerr)
+		}
+	}
 	if q.getItemIdsForPlaylistStmt != nil {
 		if cerr := q.getItemIdsForPlaylistStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing getItemIdsForPlaylistStmt: %w", cerr)
 		}
 	}
-	if q.getNextPairStmt != nil {
-		if cerr := q.getNextPairStmt.Close(); cerr != nil {
-			err = fmt.Errorf("error closing getNextPairStmt: %w", cerr)
+	if q.getLatestMatchForSessionStmt != nil {
+		if cerr := q.getLatestMatchForSessionStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getLatestMatchForSessionStmt: %w", cerr)
+		}
+	}
+	if q.getMatchesForSessionStmt != nil {
+		if cerr := q.getMatchesForSessionStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getMatchesForSessionStmt: %w", cerr)
 		}
 	}
 	if q.getNonActiveUserSessionsStmt != nil {
@@ -221,11 +385,41 @@ func (q *Queries) Close() error {
 			err = fmt.Errorf("error closing getPlaylistsForUserStmt: %w", cerr)
 		}
 	}
+	if q.getRankingForSessionStmt != nil {
+		if cerr := q.getRankingForSessionStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getRankingForSessionStmt: %w", cerr)
+		}
+	}
+	if q.getRatingStmt != nil {
+		if cerr := q.getRatingStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getRatingStmt: %w", cerr)
+		}
+	}
+	if q.getRatingLeaderboardStmt != nil {
+		if cerr := q.getRatingLeaderboardStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getRatingLeaderboardStmt: %w", cerr)
+		}
+	}
+	if q.getRemainingItemsStmt != nil {
+		if cerr := q.getRemainingItemsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getRemainingItemsStmt: %w", cerr)
+		}
+	}
 	if q.getSessionStmt != nil {
 		if cerr := q.getSessionStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing getSessionStmt: %w", cerr)
 		}
 	}
+	if q.getSessionsForUserStmt != nil {
+		if cerr := q.getSessionsForUserStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getSessionsForUserStmt: %w", cerr)
+		}
+	}
+	if q.getSpotifyTokenStmt != nil {
+		if cerr := q.getSpotifyTokenStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getSpotifyTokenStmt: %w", cerr)
+		}
+	}
 	if q.getStatistics1Stmt != nil {
 		if cerr := q.getStatistics1Stmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing getStatistics1Stmt: %w", cerr)
@@ -246,11 +440,41 @@ func (q *Queries) Close() error {
 			err = fmt.Errorf("error closing initializePossibleNextItemsForSessionStmt: %w", cerr)
 		}
 	}
+	if q.resetCurrentSessionForGroupMembersStmt != nil {
+		if cerr := q.resetCurrentSessionForGroupMembersStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing resetCurrentSessionForGroupMembersStmt: %w", cerr)
+		}
+	}
+	if q.resetPossibleNextItemsForSessionStmt != nil {
+		if cerr := q.resetPossibleNextItemsForSessionStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing resetPossibleNextItemsForSessionStmt: %w", cerr)
+		}
+	}
+	if q.reviveItemsWithFewestLossesStmt != nil {
+		if cerr := q.reviveItemsWithFewestLossesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing reviveItemsWithFewestLossesStmt: %w", cerr)
+		}
+	}
 	if q.setCurrentRoundStmt != nil {
 		if cerr := q.setCurrentRoundStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing setCurrentRoundStmt: %w", cerr)
 		}
 	}
+	if q.setGroupPairStmt != nil {
+		if cerr := q.setGroupPairStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing setGroupPairStmt: %w", cerr)
+		}
+	}
+	if q.setSeedStmt != nil {
+		if cerr := q.setSeedStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing setSeedStmt: %w", cerr)
+		}
+	}
+	if q.setSpotifyTokenStmt != nil {
+		if cerr := q.setSpotifyTokenStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing setSpotifyTokenStmt: %w", cerr)
+		}
+	}
 	if q.setUserSessionStmt != nil {
 		if cerr := q.setUserSessionStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing setUserSessionStmt: %w", cerr)
@@ -300,33 +524,61 @@ func (q *Queries) queryRow(ctx context.Context, stmt *sql.Stmt, query string, ar
 type Queries struct {
 	db                                        DBTX
 	tx                                        *sql.Tx
+	addGroupMemberStmt                        *sql.Stmt
+	addGroupSessionStmt                       *sql.Stmt
 	addMatchStmt                              *sql.Stmt
+	addOrUpdateGroupVoteStmt                  *sql.Stmt
 	addOrUpdatePlaylistStmt                   *sql.Stmt
 	addOrUpdatePlaylistItemStmt               *sql.Stmt
+	addOrUpdateRankingItemStmt                *sql.Stmt
+	addOrUpdateRatingStmt                     *sql.Stmt
 	addPlaylistAddedByUserStmt                *sql.Stmt
 	addPlaylistItemBelongsToPlaylistStmt      *sql.Stmt
+	addPlaylistItemIfAbsentStmt               *sql.Stmt
 	addSessionStmt                            *sql.Stmt
 	addUserStmt                               *sql.Stmt
 	countMatchesForRoundStmt                  *sql.Stmt
+	countPossibleNextItemsForSessionStmt      *sql.Stmt
+	deleteGroupVotesStmt                      *sql.Stmt
 	deleteItemFromPlaylistStmt                *sql.Stmt
+	deleteMatchStmt                           *sql.Stmt
 	deleteMatchesForSessionStmt               *sql.Stmt
 	deletePossibleNextItemsForSessionStmt     *sql.Stmt
 	deleteSessionStmt                         *sql.Stmt
+	eliminateItemsWithLossesStmt              *sql.Stmt
+	getActiveGroupMembersStmt                 *sql.Stmt
+	getAllSessionsStmt                        *sql.Stmt
 	getAllWinnersForUserStmt                  *sql.Stmt
 	getCurrentRoundStmt                       *sql.Stmt
+	getGroupSessionStmt                       *sql.Stmt
+	getGroupSessionByInviteCodeStmt           *sql.Stmt
+	getGroupVotesStmt                         *sql.Stmt
 	getItemIdsForPlaylistStmt                 *sql.Stmt
-	getNextPairStmt                           *sql.Stmt
+	getLatestMatchForSessionStmt              *sql.Stmt
+	getMatchesForSessionStmt                  *sql.Stmt
 	getNonActiveUserSessionsStmt              *sql.Stmt
 	getNumberOfMatchesCompletedStmt           *sql.Stmt
 	getPlaylistStmt                           *sql.Stmt
 	getPlaylistItemStmt                       *sql.Stmt
 	getPlaylistsForUserStmt                   *sql.Stmt
+	getRankingForSessionStmt                  *sql.Stmt
+	getRatingStmt                             *sql.Stmt
+	getRatingLeaderboardStmt                  *sql.Stmt
+	getRemainingItemsStmt                     *sql.Stmt
 	getSessionStmt                            *sql.Stmt
+	getSessionsForUserStmt                    *sql.Stmt
+	getSpotifyTokenStmt                       *sql.Stmt
 	getStatistics1Stmt                        *sql.Stmt
 	getUserStmt                               *sql.Stmt
 	getWinnerStmt                             *sql.Stmt
 	initializePossibleNextItemsForSessionStmt *sql.Stmt
+	resetCurrentSessionForGroupMembersStmt    *sql.Stmt
+	resetPossibleNextItemsForSessionStmt      *sql.Stmt
+	reviveItemsWithFewestLossesStmt           *sql.Stmt
 	setCurrentRoundStmt                       *sql.Stmt
+	setGroupPairStmt                          *sql.Stmt
+	setSeedStmt                               *sql.Stmt
+	setSpotifyTokenStmt                       *sql.Stmt
 	setUserSessionStmt                        *sql.Stmt
 	setWinnerStmt                             *sql.Stmt
 }
@@ -335,33 +587,61 @@ func (q *Queries) WithTx(tx *sql.Tx) *Queries {
 	return &Queries{
 		db:                                        tx,
 		tx:                                        tx,
+		addGroupMemberStmt:                        q.addGroupMemberStmt,
+		addGroupSessionStmt:                       q.addGroupSessionStmt,
 		addMatchStmt:                              q.addMatchStmt,
+		addOrUpdateGroupVoteStmt:                  q.addOrUpdateGroupVoteStmt,
 		addOrUpdatePlaylistStmt:                   q.addOrUpdatePlaylistStmt,
 		addOrUpdatePlaylistItemStmt:               q.addOrUpdatePlaylistItemStmt,
+		addOrUpdateRankingItemStmt:                q.addOrUpdateRankingItemStmt,
+		addOrUpdateRatingStmt:                     q.addOrUpdateRatingStmt,
 		addPlaylistAddedByUserStmt:                q.addPlaylistAddedByUserStmt,
 		addPlaylistItemBelongsToPlaylistStmt:      q.addPlaylistItemBelongsToPlaylistStmt,
+		addPlaylistItemIfAbsentStmt:               q.addPlaylistItemIfAbsentStmt,
 		addSessionStmt:                            q.addSessionStmt,
 		addUserStmt:                               q.addUserStmt,
 		countMatchesForRoundStmt:                  q.countMatchesForRoundStmt,
+		countPossibleNextItemsForSessionStmt:      q.countPossibleNextItemsForSessionStmt,
+		deleteGroupVotesStmt:                      q.deleteGroupVotesStmt,
 		deleteItemFromPlaylistStmt:                q.deleteItemFromPlaylistStmt,
+		deleteMatchStmt:                           q.deleteMatchStmt,
 		deleteMatchesForSessionStmt:               q.deleteMatchesForSessionStmt,
 		deletePossibleNextItemsForSessionStmt:     q.deletePossibleNextItemsForSessionStmt,
 		deleteSessionStmt:                         q.deleteSessionStmt,
+		eliminateItemsWithLossesStmt:              q.eliminateItemsWithLossesStmt,
+		getActiveGroupMembersStmt:                 q.getActiveGroupMembersStmt,
+		getAllSessionsStmt:                        q.getAllSessionsStmt,
 		getAllWinnersForUserStmt:                  q.getAllWinnersForUserStmt,
 		getCurrentRoundStmt:                       q.getCurrentRoundStmt,
+		getGroupSessionStmt:                       q.getGroupSessionStmt,
+		getGroupSessionByInviteCodeStmt:           q.getGroupSessionByInviteCodeStmt,
+		getGroupVotesStmt:                         q.getGroupVotesStmt,
 		getItemIdsForPlaylistStmt:                 q.getItemIdsForPlaylistStmt,
-		getNextPairStmt:                           q.getNextPairStmt,
+		getLatestMatchForSessionStmt:              q.getLatestMatchForSessionStmt,
+		getMatchesForSessionStmt:                  q.getMatchesForSessionStmt,
 		getNonActiveUserSessionsStmt:              q.getNonActiveUserSessionsStmt,
 		getNumberOfMatchesCompletedStmt:           q.getNumberOfMatchesCompletedStmt,
 		getPlaylistStmt:                           q.getPlaylistStmt,
 		getPlaylistItemStmt:                       q.getPlaylistItemStmt,
 		getPlaylistsForUserStmt:                   q.getPlaylistsForUserStmt,
+		getRankingForSessionStmt:                  q.getRankingForSessionStmt,
+		getRatingStmt:                             q.getRatingStmt,
+		getRatingLeaderboardStmt:                  q.getRatingLeaderboardStmt,
+		getRemainingItemsStmt:                     q.getRemainingItemsStmt,
 		getSessionStmt:                            q.getSessionStmt,
+		getSessionsForUserStmt:                    q.getSessionsForUserStmt,
+		getSpotifyTokenStmt:                       q.getSpotifyTokenStmt,
 		getStatistics1Stmt:                        q.getStatistics1Stmt,
 		getUserStmt:                               q.getUserStmt,
 		getWinnerStmt:                             q.getWinnerStmt,
 		initializePossibleNextItemsForSessionStmt: q.initializePossibleNextItemsForSessionStmt,
+		resetCurrentSessionForGroupMembersStmt:    q.resetCurrentSessionForGroupMembersStmt,
+		resetPossibleNextItemsForSessionStmt:      q.resetPossibleNextItemsForSessionStmt,
+		reviveItemsWithFewestLossesStmt:           q.reviveItemsWithFewestLossesStmt,
 		setCurrentRoundStmt:                       q.setCurrentRoundStmt,
+		setGroupPairStmt:                          q.setGroupPairStmt,
+		setSeedStmt:                               q.setSeedStmt,
+		setSpotifyTokenStmt:                       q.setSpotifyTokenStmt,
 		setUserSessionStmt:                        q.setUserSessionStmt,
 		setWinnerStmt:                             q.setWinnerStmt,
 	}
@@ -6,8 +6,31 @@ package db
 
 import (
 	"database/sql"
+	"time"
 )
 
+type GroupMember struct {
+	Session int64
+	User    string
+}
+
+type GroupSession struct {
+	Session     int64
+	InviteCode  string
+	VoteTimeout int64
+	PairFirst   sql.NullString
+	PairSecond  sql.NullString
+	PairStarted sql.NullTime
+}
+
+type GroupVote struct {
+	Session int64
+	User    string
+	Winner  string
+	Loser   string
+	Outcome string
+}
+
 type Match struct {
 	ID                int64
 	Session           int64
@@ -15,6 +38,8 @@ type Match struct {
 	Winner            string
 	Loser             string
 	CreationTimestamp sql.NullTime
+	RatingDelta       sql.NullFloat64
+	Outcome           string
 }
 
 type Playlist struct {
@@ -46,6 +71,23 @@ type PossibleNextItem struct {
 	PlaylistItem string
 	Lost         int64
 	WonRound     int64
+	Wins         int64
+	Losses       int64
+	PlayedRound  int64
+	Seed         sql.NullInt64
+}
+
+type Ranking struct {
+	Session      int64
+	PlaylistItem string
+	Position     int64
+}
+
+type Rating struct {
+	User         string
+	PlaylistItem string
+	Rating       float64
+	Matches      int64
 }
 
 type Session struct {
@@ -55,6 +97,17 @@ type Session struct {
 	User              string
 	Winner            sql.NullString
 	CreationTimestamp sql.NullTime
+	Mode              string
+	Rounds            int64
+	RandomSeed        int64
+}
+
+type SpotifyToken struct {
+	User         string
+	AccessToken  string
+	RefreshToken string
+	TokenType    string
+	Expiry       time.Time
 }
 
 type User struct {
@@ -65,6 +65,30 @@ func (q *Queries) AddPlaylistItemBelongsToPlaylist(ctx context.Context, arg AddP
 	return err
 }
 
+const addPlaylistItemIfAbsent = `-- name: AddPlaylistItemIfAbsent :exec
+INSERT OR IGNORE INTO playlist_item
+(id, title, artists, image, has_valid_spotify_id) VALUES (?, ?, ?, ?, ?)
+`
+
+type AddPlaylistItemIfAbsentParams struct {
+	ID                string
+	Title             sql.NullString
+	Artists           sql.NullString
+	Image             sql.NullString
+	HasValidSpotifyID int64
+}
+
+func (q *Queries) AddPlaylistItemIfAbsent(ctx context.Context, arg AddPlaylistItemIfAbsentParams) error {
+	_, err := q.exec(ctx, q.addPlaylistItemIfAbsentStmt, addPlaylistItemIfAbsent,
+		arg.ID,
+		arg.Title,
+		arg.Artists,
+		arg.Image,
+		arg.HasValidSpotifyID,
+	)
+	return err
+}
+
 const deleteItemFromPlaylist = `-- name: DeleteItemFromPlaylist :exec
 DELETE FROM playlist_item_belongs_to_playlist WHERE playlist = ? AND playlist_item = ?
 `
@@ -7,8 +7,21 @@ package db
 
 import (
 	"context"
+	"database/sql"
 )
 
+const countPossibleNextItemsForSession = `-- name: CountPossibleNextItemsForSession :one
+SELECT COUNT(*) FROM possible_next_items
+WHERE session = ?
+`
+
+func (q *Queries) CountPossibleNextItemsForSession(ctx context.Context, session int64) (int64, error) {
+	row := q.queryRow(ctx, q.countPossibleNextItemsForSessionStmt, countPossibleNextItemsForSession, session)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const deletePossibleNextItemsForSession = `-- name: DeletePossibleNextItemsForSession :exec
 DELETE FROM possible_next_items WHERE session = ?
 `
@@ -18,34 +31,60 @@ func (q *Queries) DeletePossibleNextItemsForSession(ctx context.Context, session
 	return err
 }
 
-const getNextPair = `-- name: GetNextPair :many
-SELECT item.id, item.title, item.artists, item.image, item.has_valid_spotify_id 
-FROM possible_next_items pn 
+const eliminateItemsWithLosses = `-- name: EliminateItemsWithLosses :exec
+UPDATE possible_next_items SET lost = TRUE
+WHERE session = ? AND losses >= ?
+`
+
+type EliminateItemsWithLossesParams struct {
+	Session int64
+	Losses  int64
+}
+
+func (q *Queries) EliminateItemsWithLosses(ctx context.Context, arg EliminateItemsWithLossesParams) error {
+	_, err := q.exec(ctx, q.eliminateItemsWithLossesStmt, eliminateItemsWithLosses, arg.Session, arg.Losses)
+	return err
+}
+
+const getRemainingItems = `-- name: GetRemainingItems :many
+SELECT item.id, item.title, item.artists, item.image, item.has_valid_spotify_id, pn.wins, pn.losses, pn.played_round, pn.seed
+FROM possible_next_items pn
 INNER JOIN playlist_item item ON pn.playlist_item = item.id
-WHERE pn.session = ? AND pn.lost = FALSE AND pn.won_round != ?2
-ORDER BY RANDOM() DESC LIMIT 2
+WHERE pn.session = ? AND pn.lost = FALSE
+ORDER BY item.id
 `
 
-type GetNextPairParams struct {
-	Session      int64
-	CurrentRound int64
+type GetRemainingItemsRow struct {
+	ID                string
+	Title             sql.NullString
+	Artists           sql.NullString
+	Image             sql.NullString
+	HasValidSpotifyID int64
+	Wins              int64
+	Losses            int64
+	PlayedRound       int64
+	Seed              sql.NullInt64
 }
 
-func (q *Queries) GetNextPair(ctx context.Context, arg GetNextPairParams) ([]PlaylistItem, error) {
-	rows, err := q.query(ctx, q.getNextPairStmt, getNextPair, arg.Session, arg.CurrentRound)
+func (q *Queries) GetRemainingItems(ctx context.Context, session int64) ([]GetRemainingItemsRow, error) {
+	rows, err := q.query(ctx, q.getRemainingItemsStmt, getRemainingItems, session)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []PlaylistItem
+	var items []GetRemainingItemsRow
 	for rows.Next() {
-		var i PlaylistItem
+		var i GetRemainingItemsRow
 		if err := rows.Scan(
 			&i.ID,
 			&i.Title,
 			&i.Artists,
 			&i.Image,
 			&i.HasValidSpotifyID,
+			&i.Wins,
+			&i.Losses,
+			&i.PlayedRound,
+			&i.Seed,
 		); err != nil {
 			return nil, err
 		}
@@ -77,3 +116,61 @@ func (q *Queries) InitializePossibleNextItemsForSession(ctx context.Context, arg
 	_, err := q.exec(ctx, q.initializePossibleNextItemsForSessionStmt, initializePossibleNextItemsForSession, arg.Session, arg.Playlist)
 	return err
 }
+
+const resetPossibleNextItemsForSession = `-- name: ResetPossibleNextItemsForSession :exec
+UPDATE possible_next_items
+SET lost = FALSE,
+	wins = (
+		SELECT COUNT(*) FROM match m
+		WHERE m.session = possible_next_items.session AND m.outcome = 'win' AND m.winner = possible_next_items.playlist_item
+	),
+	losses = (
+		SELECT COUNT(*) FROM match m
+		WHERE m.session = possible_next_items.session AND m.outcome = 'win' AND m.loser = possible_next_items.playlist_item
+	),
+	won_round = (
+		SELECT IFNULL(MAX(m.round_number), -1) FROM match m
+		WHERE m.session = possible_next_items.session
+		AND ((m.outcome = 'win' AND m.winner = possible_next_items.playlist_item)
+			OR (m.outcome = 'tie' AND (m.winner = possible_next_items.playlist_item OR m.loser = possible_next_items.playlist_item)))
+	),
+	played_round = (
+		SELECT IFNULL(MAX(m.round_number), -1) FROM match m
+		WHERE m.session = possible_next_items.session AND m.outcome != 'skip'
+		AND (m.winner = possible_next_items.playlist_item OR m.loser = possible_next_items.playlist_item)
+	)
+WHERE session = ?
+`
+
+func (q *Queries) ResetPossibleNextItemsForSession(ctx context.Context, session int64) error {
+	_, err := q.exec(ctx, q.resetPossibleNextItemsForSessionStmt, resetPossibleNextItemsForSession, session)
+	return err
+}
+
+const reviveItemsWithFewestLosses = `-- name: ReviveItemsWithFewestLosses :exec
+UPDATE possible_next_items SET lost = FALSE
+WHERE session = ?1 AND losses = (
+	SELECT MIN(pn.losses) FROM possible_next_items pn WHERE pn.session = ?1
+)
+`
+
+func (q *Queries) ReviveItemsWithFewestLosses(ctx context.Context, session int64) error {
+	_, err := q.exec(ctx, q.reviveItemsWithFewestLossesStmt, reviveItemsWithFewestLosses, session)
+	return err
+}
+
+const setSeed = `-- name: SetSeed :exec
+UPDATE possible_next_items SET seed = ?
+WHERE session = ? AND playlist_item = ?
+`
+
+type SetSeedParams struct {
+	Seed         sql.NullInt64
+	Session      int64
+	PlaylistItem string
+}
+
+func (q *Queries) SetSeed(ctx context.Context, arg SetSeedParams) error {
+	_, err := q.exec(ctx, q.setSeedStmt, setSeed, arg.Seed, arg.Session, arg.PlaylistItem)
+	return err
+}
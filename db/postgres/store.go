@@ -0,0 +1,307 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/bafto/FindFavouriteSong/db"
+)
+
+// sqlc generates the same types for the postgres queries as for the sqlite ones,
+// store converts them, so postgres can be used as db.Store
+
+type store struct {
+	q *Queries
+}
+
+func NewStore(q *Queries) db.Store {
+	return store{q}
+}
+
+func (s store) WithTx(tx *sql.Tx) db.Store {
+	return store{s.q.WithTx(tx)}
+}
+
+func (s store) Close() error {
+	return s.q.Close()
+}
+
+// keeps nil slices nil, like the sqlite queries return them
+func convertSlice[From, To any](items []From, convert func(From) To) []To {
+	if items == nil {
+		return nil
+	}
+	converted := make([]To, len(items))
+	for i, item := range items {
+		converted[i] = convert(item)
+	}
+	return converted
+}
+
+func (s store) AddGroupMember(ctx context.Context, arg db.AddGroupMemberParams) error {
+	return s.q.AddGroupMember(ctx, AddGroupMemberParams(arg))
+}
+
+func (s store) AddGroupSession(ctx context.Context, arg db.AddGroupSessionParams) error {
+	return s.q.AddGroupSession(ctx, AddGroupSessionParams(arg))
+}
+
+func (s store) AddMatch(ctx context.Context, arg db.AddMatchParams) error {
+	return s.q.AddMatch(ctx, AddMatchParams(arg))
+}
+
+func (s store) AddOrUpdateGroupVote(ctx context.Context, arg db.AddOrUpdateGroupVoteParams) error {
+	return s.q.AddOrUpdateGroupVote(ctx, AddOrUpdateGroupVoteParams(arg))
+}
+
+func (s store) AddOrUpdatePlaylist(ctx context.Context, arg db.AddOrUpdatePlaylistParams) error {
+	return s.q.AddOrUpdatePlaylist(ctx, AddOrUpdatePlaylistParams(arg))
+}
+
+func (s store) AddOrUpdatePlaylistItem(ctx context.Context, arg db.AddOrUpdatePlaylistItemParams) error {
+	return s.q.AddOrUpdatePlaylistItem(ctx, AddOrUpdatePlaylistItemParams(arg))
+}
+
+func (s store) AddOrUpdateRankingItem(ctx context.Context, arg db.AddOrUpdateRankingItemParams) error {
+	return s.q.AddOrUpdateRankingItem(ctx, AddOrUpdateRankingItemParams(arg))
+}
+
+func (s store) AddOrUpdateRating(ctx context.Context, arg db.AddOrUpdateRatingParams) error {
+	return s.q.AddOrUpdateRating(ctx, AddOrUpdateRatingParams(arg))
+}
+
+func (s store) AddPlaylistAddedByUser(ctx context.Context, arg db.AddPlaylistAddedByUserParams) error {
+	return s.q.AddPlaylistAddedByUser(ctx, AddPlaylistAddedByUserParams(arg))
+}
+
+func (s store) AddPlaylistItemBelongsToPlaylist(ctx context.Context, arg db.AddPlaylistItemBelongsToPlaylistParams) error {
+	return s.q.AddPlaylistItemBelongsToPlaylist(ctx, AddPlaylistItemBelongsToPlaylistParams(arg))
+}
+
+func (s store) AddPlaylistItemIfAbsent(ctx context.Context, arg db.AddPlaylistItemIfAbsentParams) error {
+	return s.q.AddPlaylistItemIfAbsent(ctx, AddPlaylistItemIfAbsentParams(arg))
+}
+
+func (s store) AddSession(ctx context.Context, arg db.AddSessionParams) (int64, error) {
+	return s.q.AddSession(ctx, AddSessionParams(arg))
+}
+
+func (s store) AddUser(ctx context.Context, id string) (db.User, error) {
+	item, err := s.q.AddUser(ctx, id)
+	return db.User(item), err
+}
+
+func (s store) CountMatchesForRound(ctx context.Context, arg db.CountMatchesForRoundParams) (int64, error) {
+	return s.q.CountMatchesForRound(ctx, CountMatchesForRoundParams(arg))
+}
+
+func (s store) CountPossibleNextItemsForSession(ctx context.Context, session int64) (int64, error) {
+	return s.q.CountPossibleNextItemsForSession(ctx, session)
+}
+
+func (s store) DeleteGroupVotes(ctx context.Context, session int64) error {
+	return s.q.DeleteGroupVotes(ctx, session)
+}
+
+func (s store) DeleteItemFromPlaylist(ctx context.Context, arg db.DeleteItemFromPlaylistParams) error {
+	return s.q.DeleteItemFromPlaylist(ctx, DeleteItemFromPlaylistParams(arg))
+}
+
+func (s store) DeleteMatch(ctx context.Context, id int64) error {
+	return s.q.DeleteMatch(ctx, id)
+}
+
+func (s store) DeleteMatchesForSession(ctx context.Context, session int64) error {
+	return s.q.DeleteMatchesForSession(ctx, session)
+}
+
+func (s store) DeletePossibleNextItemsForSession(ctx context.Context, session int64) error {
+	return s.q.DeletePossibleNextItemsForSession(ctx, session)
+}
+
+func (s store) DeleteSession(ctx context.Context, id int64) error {
+	return s.q.DeleteSession(ctx, id)
+}
+
+func (s store) EliminateItemsWithLosses(ctx context.Context, arg db.EliminateItemsWithLossesParams) error {
+	return s.q.EliminateItemsWithLosses(ctx, EliminateItemsWithLossesParams(arg))
+}
+
+func (s store) GetActiveGroupMembers(ctx context.Context, session int64) ([]string, error) {
+	return s.q.GetActiveGroupMembers(ctx, session)
+}
+
+func (s store) GetAllSessions(ctx context.Context) ([]db.Session, error) {
+	items, err := s.q.GetAllSessions(ctx)
+	return convertSlice(items, func(item Session) db.Session {
+		return db.Session(item)
+	}), err
+}
+
+func (s store) GetAllWinnersForUser(ctx context.Context, user string) ([]sql.NullString, error) {
+	return s.q.GetAllWinnersForUser(ctx, user)
+}
+
+func (s store) GetCurrentRound(ctx context.Context, id int64) (int64, error) {
+	return s.q.GetCurrentRound(ctx, id)
+}
+
+func (s store) GetGroupSession(ctx context.Context, session int64) (db.GroupSession, error) {
+	item, err := s.q.GetGroupSession(ctx, session)
+	return db.GroupSession(item), err
+}
+
+func (s store) GetGroupSessionByInviteCode(ctx context.Context, inviteCode string) (db.GroupSession, error) {
+	item, err := s.q.GetGroupSessionByInviteCode(ctx, inviteCode)
+	return db.GroupSession(item), err
+}
+
+func (s store) GetGroupVotes(ctx context.Context, session int64) ([]db.GroupVote, error) {
+	items, err := s.q.GetGroupVotes(ctx, session)
+	return convertSlice(items, func(item GroupVote) db.GroupVote {
+		return db.GroupVote(item)
+	}), err
+}
+
+func (s store) GetItemIdsForPlaylist(ctx context.Context, playlist string) ([]string, error) {
+	return s.q.GetItemIdsForPlaylist(ctx, playlist)
+}
+
+func (s store) GetLatestMatchForSession(ctx context.Context, session int64) (db.Match, error) {
+	item, err := s.q.GetLatestMatchForSession(ctx, session)
+	return db.Match(item), err
+}
+
+func (s store) GetMatchesForSession(ctx context.Context, session int64) ([]db.Match, error) {
+	items, err := s.q.GetMatchesForSession(ctx, session)
+	return convertSlice(items, func(item Match) db.Match {
+		return db.Match(item)
+	}), err
+}
+
+func (s store) GetNonActiveUserSessions(ctx context.Context, arg db.GetNonActiveUserSessionsParams) ([]db.Session, error) {
+	items, err := s.q.GetNonActiveUserSessions(ctx, GetNonActiveUserSessionsParams(arg))
+	return convertSlice(items, func(item Session) db.Session {
+		return db.Session(item)
+	}), err
+}
+
+func (s store) GetNumberOfMatchesCompleted(ctx context.Context, session int64) (int64, error) {
+	return s.q.GetNumberOfMatchesCompleted(ctx, session)
+}
+
+func (s store) GetPlaylist(ctx context.Context, id string) (db.Playlist, error) {
+	item, err := s.q.GetPlaylist(ctx, id)
+	return db.Playlist(item), err
+}
+
+func (s store) GetPlaylistItem(ctx context.Context, id string) (db.PlaylistItem, error) {
+	item, err := s.q.GetPlaylistItem(ctx, id)
+	return db.PlaylistItem(item), err
+}
+
+func (s store) GetPlaylistsForUser(ctx context.Context, user string) ([]db.Playlist, error) {
+	items, err := s.q.GetPlaylistsForUser(ctx, user)
+	return convertSlice(items, func(item Playlist) db.Playlist {
+		return db.Playlist(item)
+	}), err
+}
+
+func (s store) GetRankingForSession(ctx context.Context, session int64) ([]db.GetRankingForSessionRow, error) {
+	items, err := s.q.GetRankingForSession(ctx, session)
+	return convertSlice(items, func(item GetRankingForSessionRow) db.GetRankingForSessionRow {
+		return db.GetRankingForSessionRow(item)
+	}), err
+}
+
+func (s store) GetRating(ctx context.Context, arg db.GetRatingParams) (db.Rating, error) {
+	item, err := s.q.GetRating(ctx, GetRatingParams(arg))
+	return db.Rating(item), err
+}
+
+func (s store) GetRatingLeaderboard(ctx context.Context, arg db.GetRatingLeaderboardParams) ([]db.GetRatingLeaderboardRow, error) {
+	items, err := s.q.GetRatingLeaderboard(ctx, GetRatingLeaderboardParams(arg))
+	return convertSlice(items, func(item GetRatingLeaderboardRow) db.GetRatingLeaderboardRow {
+		return db.GetRatingLeaderboardRow(item)
+	}), err
+}
+
+func (s store) GetRemainingItems(ctx context.Context, session int64) ([]db.GetRemainingItemsRow, error) {
+	items, err := s.q.GetRemainingItems(ctx, session)
+	return convertSlice(items, func(item GetRemainingItemsRow) db.GetRemainingItemsRow {
+		return db.GetRemainingItemsRow(item)
+	}), err
+}
+
+func (s store) GetSession(ctx context.Context, id int64) (db.Session, error) {
+	item, err := s.q.GetSession(ctx, id)
+	return db.Session(item), err
+}
+
+func (s store) GetSessionsForUser(ctx context.Context, user string) ([]db.Session, error) {
+	items, err := s.q.GetSessionsForUser(ctx, user)
+	return convertSlice(items, func(item Session) db.Session {
+		return db.Session(item)
+	}), err
+}
+
+func (s store) GetSpotifyToken(ctx context.Context, user string) (db.SpotifyToken, error) {
+	item, err := s.q.GetSpotifyToken(ctx, user)
+	return db.SpotifyToken(item), err
+}
+
+func (s store) GetStatistics1(ctx context.Context, arg db.GetStatistics1Params) ([]db.GetStatistics1Row, error) {
+	items, err := s.q.GetStatistics1(ctx, GetStatistics1Params(arg))
+	return convertSlice(items, func(item GetStatistics1Row) db.GetStatistics1Row {
+		return db.GetStatistics1Row(item)
+	}), err
+}
+
+func (s store) GetUser(ctx context.Context, id string) (db.User, error) {
+	item, err := s.q.GetUser(ctx, id)
+	return db.User(item), err
+}
+
+func (s store) GetWinner(ctx context.Context, id int64) (sql.NullString, error) {
+	return s.q.GetWinner(ctx, id)
+}
+
+func (s store) InitializePossibleNextItemsForSession(ctx context.Context, arg db.InitializePossibleNextItemsForSessionParams) error {
+	return s.q.InitializePossibleNextItemsForSession(ctx, InitializePossibleNextItemsForSessionParams(arg))
+}
+
+func (s store) ResetCurrentSessionForGroupMembers(ctx context.Context, session sql.NullInt64) error {
+	return s.q.ResetCurrentSessionForGroupMembers(ctx, session)
+}
+
+func (s store) ResetPossibleNextItemsForSession(ctx context.Context, session int64) error {
+	return s.q.ResetPossibleNextItemsForSession(ctx, session)
+}
+
+func (s store) ReviveItemsWithFewestLosses(ctx context.Context, session int64) error {
+	return s.q.ReviveItemsWithFewestLosses(ctx, session)
+}
+
+func (s store) SetCurrentRound(ctx context.Context, arg db.SetCurrentRoundParams) error {
+	return s.q.SetCurrentRound(ctx, SetCurrentRoundParams(arg))
+}
+
+func (s store) SetGroupPair(ctx context.Context, arg db.SetGroupPairParams) error {
+	return s.q.SetGroupPair(ctx, SetGroupPairParams(arg))
+}
+
+func (s store) SetSeed(ctx context.Context, arg db.SetSeedParams) error {
+	return s.q.SetSeed(ctx, SetSeedParams(arg))
+}
+
+func (s store) SetSpotifyToken(ctx context.Context, arg db.SetSpotifyTokenParams) error {
+	return s.q.SetSpotifyToken(ctx, SetSpotifyTokenParams(arg))
+}
+
+func (s store) SetUserSession(ctx context.Context, arg db.SetUserSessionParams) error {
+	return s.q.SetUserSession(ctx, SetUserSessionParams(arg))
+}
+
+func (s store) SetWinner(ctx context.Context, arg db.SetWinnerParams) error {
+	return s.q.SetWinner(ctx, SetWinnerParams(arg))
+}
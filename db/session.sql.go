@@ -12,7 +12,7 @@ import (
 
 const addMatch = `-- name: AddMatch :exec
 INSERT INTO match
-(id, session, round_number, winner, loser, creation_timestamp) VALUES (NULL, ?, ?, ?, ?, CURRENT_TIMESTAMP)
+(id, session, round_number, winner, loser, creation_timestamp, rating_delta, outcome) VALUES (NULL, ?, ?, ?, ?, CURRENT_TIMESTAMP, ?, ?)
 `
 
 type AddMatchParams struct {
@@ -20,6 +20,8 @@ type AddMatchParams struct {
 	RoundNumber int64
 	Winner      string
 	Loser       string
+	RatingDelta sql.NullFloat64
+	Outcome     string
 }
 
 func (q *Queries) AddMatch(ctx context.Context, arg AddMatchParams) error {
@@ -28,23 +30,34 @@ func (q *Queries) AddMatch(ctx context.Context, arg AddMatchParams) error {
 		arg.RoundNumber,
 		arg.Winner,
 		arg.Loser,
+		arg.RatingDelta,
+		arg.Outcome,
 	)
 	return err
 }
 
 const addSession = `-- name: AddSession :one
 INSERT INTO session
-(id, playlist, current_round, user, winner, creation_timestamp) VALUES (NULL, ?, 0, ?, NULL, CURRENT_TIMESTAMP)
+(id, playlist, current_round, user, winner, creation_timestamp, mode, rounds, random_seed) VALUES (NULL, ?, 0, ?, NULL, CURRENT_TIMESTAMP, ?, ?, ?)
 RETURNING session.id
 `
 
 type AddSessionParams struct {
-	Playlist string
-	User     string
+	Playlist   string
+	User       string
+	Mode       string
+	Rounds     int64
+	RandomSeed int64
 }
 
 func (q *Queries) AddSession(ctx context.Context, arg AddSessionParams) (int64, error) {
-	row := q.queryRow(ctx, q.addSessionStmt, addSession, arg.Playlist, arg.User)
+	row := q.queryRow(ctx, q.addSessionStmt, addSession,
+		arg.Playlist,
+		arg.User,
+		arg.Mode,
+		arg.Rounds,
+		arg.RandomSeed,
+	)
 	var id int64
 	err := row.Scan(&id)
 	return id, err
@@ -52,7 +65,7 @@ func (q *Queries) AddSession(ctx context.Context, arg AddSessionParams) (int64,
 
 const countMatchesForRound = `-- name: CountMatchesForRound :one
 SELECT COUNT(*) FROM match
-WHERE session = ? AND round_number = ?
+WHERE session = ? AND round_number = ? AND outcome != 'skip'
 `
 
 type CountMatchesForRoundParams struct {
@@ -67,6 +80,15 @@ func (q *Queries) CountMatchesForRound(ctx context.Context, arg CountMatchesForR
 	return count, err
 }
 
+const deleteMatch = `-- name: DeleteMatch :exec
+DELETE FROM match WHERE id = ?
+`
+
+func (q *Queries) DeleteMatch(ctx context.Context, id int64) error {
+	_, err := q.exec(ctx, q.deleteMatchStmt, deleteMatch, id)
+	return err
+}
+
 const deleteMatchesForSession = `-- name: DeleteMatchesForSession :exec
 DELETE FROM match WHERE session = ?
 `
@@ -85,6 +107,44 @@ func (q *Queries) DeleteSession(ctx context.Context, id int64) error {
 	return err
 }
 
+const getAllSessions = `-- name: GetAllSessions :many
+SELECT id, playlist, current_round, user, winner, creation_timestamp, mode, rounds, random_seed FROM session
+ORDER BY id
+`
+
+func (q *Queries) GetAllSessions(ctx context.Context) ([]Session, error) {
+	rows, err := q.query(ctx, q.getAllSessionsStmt, getAllSessions)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Session
+	for rows.Next() {
+		var i Session
+		if err := rows.Scan(
+			&i.ID,
+			&i.Playlist,
+			&i.CurrentRound,
+			&i.User,
+			&i.Winner,
+			&i.CreationTimestamp,
+			&i.Mode,
+			&i.Rounds,
+			&i.RandomSeed,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getCurrentRound = `-- name: GetCurrentRound :one
 SELECT current_round FROM session
 WHERE id = ?
@@ -97,9 +157,68 @@ func (q *Queries) GetCurrentRound(ctx context.Context, id int64) (int64, error)
 	return current_round, err
 }
 
+const getLatestMatchForSession = `-- name: GetLatestMatchForSession :one
+SELECT id, session, round_number, winner, loser, creation_timestamp, rating_delta, outcome FROM match
+WHERE session = ?
+ORDER BY id DESC LIMIT 1
+`
+
+func (q *Queries) GetLatestMatchForSession(ctx context.Context, session int64) (Match, error) {
+	row := q.queryRow(ctx, q.getLatestMatchForSessionStmt, getLatestMatchForSession, session)
+	var i Match
+	err := row.Scan(
+		&i.ID,
+		&i.Session,
+		&i.RoundNumber,
+		&i.Winner,
+		&i.Loser,
+		&i.CreationTimestamp,
+		&i.RatingDelta,
+		&i.Outcome,
+	)
+	return i, err
+}
+
+const getMatchesForSession = `-- name: GetMatchesForSession :many
+SELECT id, session, round_number, winner, loser, creation_timestamp, rating_delta, outcome FROM match
+WHERE session = ?
+`
+
+func (q *Queries) GetMatchesForSession(ctx context.Context, session int64) ([]Match, error) {
+	rows, err := q.query(ctx, q.getMatchesForSessionStmt, getMatchesForSession, session)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Match
+	for rows.Next() {
+		var i Match
+		if err := rows.Scan(
+			&i.ID,
+			&i.Session,
+			&i.RoundNumber,
+			&i.Winner,
+			&i.Loser,
+			&i.CreationTimestamp,
+			&i.RatingDelta,
+			&i.Outcome,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getNumberOfMatchesCompleted = `-- name: GetNumberOfMatchesCompleted :one
 SELECT COUNT(*) FROM match
-WHERE session = ?
+WHERE session = ? AND outcome != 'skip'
 `
 
 func (q *Queries) GetNumberOfMatchesCompleted(ctx context.Context, session int64) (int64, error) {
@@ -110,7 +229,7 @@ func (q *Queries) GetNumberOfMatchesCompleted(ctx context.Context, session int64
 }
 
 const getSession = `-- name: GetSession :one
-SELECT id, playlist, current_round, user, winner, creation_timestamp FROM session
+SELECT id, playlist, current_round, user, winner, creation_timestamp, mode, rounds, random_seed FROM session
 WHERE id = ?
 `
 
@@ -124,10 +243,52 @@ func (q *Queries) GetSession(ctx context.Context, id int64) (Session, error) {
 		&i.User,
 		&i.Winner,
 		&i.CreationTimestamp,
+		&i.Mode,
+		&i.Rounds,
+		&i.RandomSeed,
 	)
 	return i, err
 }
 
+const getSessionsForUser = `-- name: GetSessionsForUser :many
+SELECT id, playlist, current_round, user, winner, creation_timestamp, mode, rounds, random_seed FROM session
+WHERE user = ?
+ORDER BY id
+`
+
+func (q *Queries) GetSessionsForUser(ctx context.Context, user string) ([]Session, error) {
+	rows, err := q.query(ctx, q.getSessionsForUserStmt, getSessionsForUser, user)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Session
+	for rows.Next() {
+		var i Session
+		if err := rows.Scan(
+			&i.ID,
+			&i.Playlist,
+			&i.CurrentRound,
+			&i.User,
+			&i.Winner,
+			&i.CreationTimestamp,
+			&i.Mode,
+			&i.Rounds,
+			&i.RandomSeed,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getWinner = `-- name: GetWinner :one
 SELECT winner FROM session
 WHERE id = ?
@@ -16,6 +16,7 @@ WITH winners AS
 session s
 INNER JOIN match m ON m.session = s.id
 WHERE s.user = ?
+AND m.outcome = 'win'
 AND s.playlist = ?2 
 AND s.winner IS NOT NULL)
 SELECT pi.id, pi.title, pi.artists, pi.image, CAST(IFNULL(ct, 0) AS INTEGER) AS points
@@ -0,0 +1,24 @@
+package db
+
+import "database/sql"
+
+// the queries of a storage backend
+// the app only uses Store, so it doesn't depend on the sql dialect of the backend
+type Store interface {
+	Querier
+	WithTx(tx *sql.Tx) Store
+	Close() error
+}
+
+// the sqlite backend
+type store struct {
+	*Queries
+}
+
+func NewStore(q *Queries) Store {
+	return store{q}
+}
+
+func (s store) WithTx(tx *sql.Tx) Store {
+	return store{s.Queries.WithTx(tx)}
+}
@@ -8,6 +8,7 @@ package db
 import (
 	"context"
 	"database/sql"
+	"time"
 )
 
 const addPlaylistAddedByUser = `-- name: AddPlaylistAddedByUser :exec
@@ -66,7 +67,7 @@ func (q *Queries) GetAllWinnersForUser(ctx context.Context, user string) ([]sql.
 }
 
 const getNonActiveUserSessions = `-- name: GetNonActiveUserSessions :many
-SELECT id, playlist, current_round, user, winner, creation_timestamp FROM session
+SELECT id, playlist, current_round, user, winner, creation_timestamp, mode, rounds, random_seed FROM session
 WHERE user = ? AND id != ?2 AND winner IS NULL
 `
 
@@ -91,6 +92,9 @@ func (q *Queries) GetNonActiveUserSessions(ctx context.Context, arg GetNonActive
 			&i.User,
 			&i.Winner,
 			&i.CreationTimestamp,
+			&i.Mode,
+			&i.Rounds,
+			&i.RandomSeed,
 		); err != nil {
 			return nil, err
 		}
@@ -133,6 +137,24 @@ func (q *Queries) GetPlaylistsForUser(ctx context.Context, user string) ([]Playl
 	return items, nil
 }
 
+const getSpotifyToken = `-- name: GetSpotifyToken :one
+SELECT user, access_token, refresh_token, token_type, expiry FROM spotify_token
+WHERE user = ?
+`
+
+func (q *Queries) GetSpotifyToken(ctx context.Context, user string) (SpotifyToken, error) {
+	row := q.queryRow(ctx, q.getSpotifyTokenStmt, getSpotifyToken, user)
+	var i SpotifyToken
+	err := row.Scan(
+		&i.User,
+		&i.AccessToken,
+		&i.RefreshToken,
+		&i.TokenType,
+		&i.Expiry,
+	)
+	return i, err
+}
+
 const getUser = `-- name: GetUser :one
 SELECT id, current_session FROM user
 WHERE id = ? LIMIT 1
@@ -145,6 +167,30 @@ func (q *Queries) GetUser(ctx context.Context, id string) (User, error) {
 	return i, err
 }
 
+const setSpotifyToken = `-- name: SetSpotifyToken :exec
+INSERT OR REPLACE INTO spotify_token
+(user, access_token, refresh_token, token_type, expiry) VALUES (?, ?, ?, ?, ?)
+`
+
+type SetSpotifyTokenParams struct {
+	User         string
+	AccessToken  string
+	RefreshToken string
+	TokenType    string
+	Expiry       time.Time
+}
+
+func (q *Queries) SetSpotifyToken(ctx context.Context, arg SetSpotifyTokenParams) error {
+	_, err := q.exec(ctx, q.setSpotifyTokenStmt, setSpotifyToken,
+		arg.User,
+		arg.AccessToken,
+		arg.RefreshToken,
+		arg.TokenType,
+		arg.Expiry,
+	)
+	return err
+}
+
 const setUserSession = `-- name: SetUserSession :exec
 UPDATE user
 SET current_session = ?
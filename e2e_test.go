@@ -0,0 +1,851 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bafto/FindFavouriteSong/tournament"
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/securecookie"
+)
+
+const (
+	e2e_user     = "e2e"
+	e2e_password = "e2e-password"
+)
+
+// an app with a fresh sqlite db, talking to a fake spotify
+type e2eApp struct {
+	t       *testing.T
+	app     *App
+	config  Config
+	db      *sql.DB
+	server  *httptest.Server
+	spotify *fakeSpotify
+}
+
+func newE2EApp(t *testing.T) *e2eApp {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	dir := t.TempDir()
+	fake := newFakeSpotify(t)
+	testConfig := Config{
+		Spotify_client_id:     fake_spotify_client_id,
+		Spotify_client_secret: fake_spotify_client_secret,
+		Spotify_accounts_url:  fake.URL,
+		Spotify_api_url:       fake.URL + "/v1",
+		Datasource:            strings.Replace(default_sqlite_data_source, "ffs.db", filepath.Join(dir, "ffs.db"), 1),
+		BackupPath:            filepath.Join(dir, "ffs.backup.db"),
+		Users:                 map[string]string{e2e_user: e2e_password},
+		GroupVoteTimeout:      time.Minute,
+		LoginTimeout:          time.Minute,
+		Log_level:             "WARN",
+		// fixed, so sessions survive restarts of the app
+		CookieAuthKey:       base64.StdEncoding.EncodeToString(securecookie.GenerateRandomKey(64)),
+		CookieEncryptionKey: base64.StdEncoding.EncodeToString(securecookie.GenerateRandomKey(32)),
+	}
+
+	// logging is set up for the process, like the commands do
+	configure_logging(testConfig.Log_level)
+
+	conn, err := create_db(context.Background(), testConfig.Datasource)
+	if err != nil {
+		t.Fatalf("could not open db: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	if err := migrate_db(context.Background(), testConfig, conn); err != nil {
+		t.Fatalf("could not migrate db: %v", err)
+	}
+
+	e2e := &e2eApp{t: t, config: testConfig, db: conn, spotify: fake}
+	e2e.start()
+	return e2e
+}
+
+// starts a new app on the db, like a restart of the server
+func (e2e *e2eApp) start() {
+	e2e.t.Helper()
+	if e2e.server != nil {
+		e2e.server.Close()
+	}
+
+	// the redirect url is only known once the server listens
+	e2e.server = httptest.NewUnstartedServer(nil)
+	e2e.t.Cleanup(e2e.server.Close)
+	e2e.config.Redirect_url = "http://" + e2e.server.Listener.Addr().String() + "/spotifyauthentication"
+
+	app, err := NewApp(e2e.config, e2e.db, nil)
+	if err != nil {
+		e2e.t.Fatalf("could not create app: %v", err)
+	}
+	e2e.t.Cleanup(func() { app.Close() })
+	e2e.app = app
+
+	e2e.server.Config.Handler = app.Router()
+	e2e.server.Start()
+}
+
+// a browser of one person, it keeps its cookies and sends the basic auth credentials to the app
+type e2eBrowser struct {
+	t      *testing.T // failed requests fail t
+	app    *e2eApp
+	client *http.Client
+}
+
+func (e2e *e2eApp) newBrowser() *e2eBrowser {
+	return e2e.newBrowserFor(e2e.t)
+}
+
+// a browser used by the (parallel) test t
+func (e2e *e2eApp) newBrowserFor(t *testing.T) *e2eBrowser {
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &e2eBrowser{
+		t:   t,
+		app: e2e,
+		client: &http.Client{
+			Jar:       jar,
+			Transport: basicAuthTransport{app: e2e},
+			// the handlers answer POSTs with 307 redirects to pages, which only accept GET
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				if req.Method != http.MethodGet {
+					return http.ErrUseLastResponse
+				}
+				return nil
+			},
+		},
+	}
+}
+
+// the app moves to a new address when it is restarted
+type basicAuthTransport struct {
+	app *e2eApp
+}
+
+func (transport basicAuthTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.URL.Host == transport.app.server.Listener.Addr().String() {
+		req = req.Clone(req.Context())
+		req.SetBasicAuth(e2e_user, e2e_password)
+	}
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+// returns the status and body of the response
+func (browser *e2eBrowser) do(method, path, contentType string, body io.Reader) (int, []byte) {
+	browser.t.Helper()
+	req, err := http.NewRequest(method, browser.app.server.URL+path, body)
+	if err != nil {
+		browser.t.Fatal(err)
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	resp, err := browser.client.Do(req)
+	if err != nil {
+		browser.t.Fatalf("%s %s failed: %v", method, path, err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		browser.t.Fatalf("could not read response of %s %s: %v", method, path, err)
+	}
+	return resp.StatusCode, respBody
+}
+
+func (browser *e2eBrowser) get(path string) (int, []byte) {
+	browser.t.Helper()
+	return browser.do(http.MethodGet, path, "", nil)
+}
+
+func (browser *e2eBrowser) postForm(path string, form url.Values) (int, []byte) {
+	browser.t.Helper()
+	return browser.do(http.MethodPost, path, "application/x-www-form-urlencoded", strings.NewReader(form.Encode()))
+}
+
+// sends body as JSON and decodes the response into result, if it is not nil
+func (browser *e2eBrowser) json(method, path string, body, result any, expectedStatus int) {
+	browser.t.Helper()
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			browser.t.Fatal(err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	status, respBody := browser.do(method, path, "application/json", reader)
+	if status != expectedStatus {
+		browser.t.Fatalf("%s %s: expected status %d, got %d: %s", method, path, expectedStatus, status, respBody)
+	}
+	if result != nil {
+		if err := json.Unmarshal(respBody, result); err != nil {
+			browser.t.Fatalf("could not decode response of %s %s: %v: %s", method, path, err, respBody)
+		}
+	}
+}
+
+// goes through the spotify login and ends on the start page
+func (browser *e2eBrowser) login() {
+	browser.t.Helper()
+	status, body := browser.get("/")
+	if status != http.StatusOK || !bytes.Contains(body, []byte("Enter the URL to your playlist")) {
+		browser.t.Fatalf("login did not end on the start page: %d %s", status, body)
+	}
+}
+
+// requests target, which may be an url of the fake spotify, without following redirects
+// returns the url the response redirects to
+func (browser *e2eBrowser) redirect(target string) string {
+	browser.t.Helper()
+	client := *browser.client
+	client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+
+	resp, err := client.Get(target)
+	if err != nil {
+		browser.t.Fatalf("GET %s failed: %v", target, err)
+	}
+	resp.Body.Close()
+	location, err := resp.Location()
+	if err != nil {
+		browser.t.Fatalf("GET %s did not redirect: %d", target, resp.StatusCode)
+	}
+	return location.String()
+}
+
+// starts a login and returns the url spotify redirects back to, which finishes it
+func (browser *e2eBrowser) startLogin() string {
+	browser.t.Helper()
+	authorizeURL := browser.redirect(browser.app.server.URL + "/")
+	if !strings.HasPrefix(authorizeURL, browser.app.spotify.URL+"/authorize") {
+		browser.t.Fatalf("expected a redirect to the spotify login, got %s", authorizeURL)
+	}
+	return browser.redirect(authorizeURL)
+}
+
+// logs in as the spotify user, instead of the user the fake logs in by default
+func (browser *e2eBrowser) loginAs(spotifyUser string) {
+	browser.t.Helper()
+	browser.useSpotifyUser(spotifyUser)
+	browser.login()
+}
+
+// the fake spotify authorizes the browser as spotifyUser
+func (browser *e2eBrowser) useSpotifyUser(spotifyUser string) {
+	browser.t.Helper()
+	fakeURL, err := url.Parse(browser.app.spotify.URL)
+	if err != nil {
+		browser.t.Fatal(err)
+	}
+	browser.client.Jar.SetCookies(fakeURL, []*http.Cookie{{Name: fake_spotify_login_cookie, Value: spotifyUser}})
+}
+
+// plays the current session of the user through the JSON API, the first item of each pair wins
+func (browser *e2eBrowser) playSession() (int64, APIItem) {
+	browser.t.Helper()
+	var user APIUser
+	browser.json(http.MethodGet, "/api/v1/me", nil, &user, http.StatusOK)
+	if user.CurrentSession == nil {
+		browser.t.Fatal("user has no current session")
+	}
+	sessionPath := fmt.Sprintf("/api/v1/sessions/%d", *user.CurrentSession)
+
+	var state APISessionState
+	browser.json(http.MethodGet, sessionPath+"/pair", nil, &state, http.StatusOK)
+	for matches := 0; state.Winner == nil; matches++ {
+		if matches > 100 {
+			browser.t.Fatal("session did not end after 100 matches")
+		}
+		if len(state.Pair) != 2 {
+			browser.t.Fatalf("expected a pair, got %v", state.Pair)
+		}
+		browser.json(http.MethodPost, sessionPath+"/matches", APINewMatch{
+			Winner:  state.Pair[0].ID,
+			Loser:   state.Pair[1].ID,
+			Outcome: tournament.OutcomeWin,
+		}, &state, http.StatusOK)
+	}
+	return *user.CurrentSession, *state.Winner
+}
+
+// imports a playlist file through the JSON API
+func (browser *e2eBrowser) importPlaylist(filename, content string) APIPlaylist {
+	browser.t.Helper()
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	file, err := form.CreateFormFile(import_file_field, filename)
+	if err != nil {
+		browser.t.Fatal(err)
+	}
+	file.Write([]byte(content))
+	form.Close()
+
+	status, respBody := browser.do(http.MethodPost, "/api/v1/playlists/import", form.FormDataContentType(), &body)
+	if status != http.StatusCreated {
+		browser.t.Fatalf("importing %s failed: %d %s", filename, status, respBody)
+	}
+	var playlist APIPlaylist
+	if err := json.Unmarshal(respBody, &playlist); err != nil {
+		browser.t.Fatalf("could not decode the imported playlist: %v: %s", err, respBody)
+	}
+	return playlist
+}
+
+func e2eTestPlaylist() fakeSpotifyPlaylist {
+	return fakeSpotifyPlaylist{
+		ID:   "37i9dQZF1DXcBWIGoYBM5M",
+		Name: "Today's Top Hits",
+		Tracks: []fakeSpotifyTrack{
+			{ID: "4uLU6hMCjMI75M1A2tKUQC", Name: "Never Gonna Give You Up", Artists: []string{"Rick Astley"}, Image: "https://i.scdn.co/image/1"},
+			{ID: "7GhIk7Il098yCjg4BQjzvb", Name: "Take On Me", Artists: []string{"a-ha"}, Image: "https://i.scdn.co/image/2"},
+			{ID: "2WfaOiMkCvy7F5fcp2zZ8L", Name: "Africa", Artists: []string{"TOTO"}},
+			{ID: "0ikz6tENMONtK6qGkOrU3c", Name: "Under Pressure", Artists: []string{"Queen", "David Bowie"}},
+			{Name: "Demo Tape", Artists: []string{"Local Band"}}, // a local file without id
+		},
+	}
+}
+
+func TestE2ELoginSelectPlaylistPlayWinner(t *testing.T) {
+	e2e := newE2EApp(t)
+	e2e.spotify.AddUser("alice", "Alice")
+	playlist := e2eTestPlaylist()
+	e2e.spotify.AddPlaylist(playlist)
+	e2e.spotify.SetPageSize(2)
+
+	browser := e2e.newBrowser()
+	browser.login()
+
+	token, err := e2e.app.queries.GetSpotifyToken(context.Background(), "alice")
+	if err != nil {
+		t.Fatalf("spotify token of alice was not stored: %v", err)
+	}
+	if token.RefreshToken == "" {
+		t.Error("stored spotify token has no refresh token")
+	}
+
+	status, body := browser.postForm("/api/select_playlist", url.Values{
+		"playlist_url": {"https://open.spotify.com/playlist/" + playlist.ID + "?si=abc"},
+		"mode":         {tournament.ModeKnockout},
+	})
+	if status != http.StatusTemporaryRedirect {
+		t.Fatalf("selecting the playlist failed: %d %s", status, body)
+	}
+
+	// 5 items in pages of 2
+	if requests := e2e.spotify.Requests("GET /v1/playlists/{id}/tracks"); requests != 3 {
+		t.Errorf("expected 3 requests for pages of playlist items, got %d", requests)
+	}
+	itemIds, err := e2e.app.queries.GetItemIdsForPlaylist(context.Background(), playlist.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(itemIds) != len(playlist.Tracks) {
+		t.Fatalf("expected %d playlist items in the db, got %d: %v", len(playlist.Tracks), len(itemIds), itemIds)
+	}
+	localItem, err := e2e.app.queries.GetPlaylistItem(context.Background(), localItemId("Demo Tape", "Local Band"))
+	if err != nil {
+		t.Fatalf("local file was not stored by title and artists: %v", err)
+	}
+	if localItem.HasValidSpotifyID != 0 {
+		t.Error("local file was stored with a valid spotify id")
+	}
+
+	status, body = browser.get("/select_song")
+	if status != http.StatusOK {
+		t.Fatalf("select_song page failed: %d %s", status, body)
+	}
+
+	sessionID, winner := browser.playSession()
+	// the first item of each pair won, so the winner never lost
+	if winner.ID == "" || winner.Title == "" {
+		t.Fatalf("invalid winner %+v", winner)
+	}
+
+	var session APISession
+	browser.json(http.MethodGet, fmt.Sprintf("/api/v1/sessions/%d", sessionID), nil, &session, http.StatusOK)
+	if session.Winner == nil || *session.Winner != winner.ID {
+		t.Errorf("session winner %v does not match the winner of the last pair %s", session.Winner, winner.ID)
+	}
+
+	var matches []APIMatch
+	browser.json(http.MethodGet, fmt.Sprintf("/api/v1/sessions/%d/matches", sessionID), nil, &matches, http.StatusOK)
+	// a knockout of n items has n-1 matches
+	if len(matches) != len(playlist.Tracks)-1 {
+		t.Errorf("expected %d matches, got %d", len(playlist.Tracks)-1, len(matches))
+	}
+	for _, match := range matches {
+		if match.Loser == winner.ID {
+			t.Errorf("winner %s lost match %d", winner.ID, match.ID)
+		}
+	}
+
+	status, body = browser.get("/winner?winner=" + url.QueryEscape(winner.ID))
+	if status != http.StatusOK || !bytes.Contains(body, []byte(winner.Title)) {
+		t.Fatalf("winner page does not show %s: %d %s", winner.Title, status, body)
+	}
+}
+
+// after a restart the spotify client of a user is created from the token in the db
+func TestE2EClientFromStoredToken(t *testing.T) {
+	e2e := newE2EApp(t)
+	e2e.spotify.AddUser("bob", "Bob")
+	playlist := e2eTestPlaylist()
+	e2e.spotify.AddPlaylist(playlist)
+
+	browser := e2e.newBrowser()
+	browser.login()
+	e2e.start()
+
+	var added APIPlaylist
+	browser.json(http.MethodPost, "/api/v1/playlists", APINewPlaylist{
+		Url: "spotify:playlist:" + playlist.ID,
+	}, &added, http.StatusCreated)
+	if added.ID != playlist.ID || added.Name != playlist.Name {
+		t.Errorf("unexpected playlist %+v", added)
+	}
+
+	var apiErr APIError
+	browser.json(http.MethodPost, "/api/v1/playlists", APINewPlaylist{
+		Url: "https://open.spotify.com/playlist/doesnotexist",
+	}, &apiErr, http.StatusNotFound)
+}
+
+func TestE2ERequiresBasicAuth(t *testing.T) {
+	e2e := newE2EApp(t)
+
+	resp, err := http.Get(e2e.server.URL + "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("expected status %d without basic auth, got %d", http.StatusUnauthorized, resp.StatusCode)
+	}
+	if requests := e2e.spotify.Requests("GET /authorize"); requests != 0 {
+		t.Errorf("unauthenticated request was redirected to spotify %d times", requests)
+	}
+}
+
+// backups are only reported by the healthcheck if they are scheduled
+func TestE2EHealthcheckWithoutScheduledBackups(t *testing.T) {
+	e2e := newE2EApp(t)
+	e2e.config.BackupDir = t.TempDir() // without a backup_interval
+	e2e.start()
+
+	var result HealthcheckResult
+	e2e.newBrowser().json(http.MethodGet, "/api/health", nil, &result, http.StatusOK)
+	if !result.Healthy {
+		t.Errorf("expected the app to be healthy: %+v", result)
+	}
+	if result.BackupStatus != nil {
+		t.Errorf("expected no backup status without scheduled backups, got %+v", *result.BackupStatus)
+	}
+}
+
+// joining a group session responds with its status
+func TestE2EJoinGroupSession(t *testing.T) {
+	e2e := newE2EApp(t)
+	e2e.spotify.AddUser("alice", "Alice")
+	e2e.spotify.AddUser("bob", "Bob")
+	playlist := e2eTestPlaylist()
+	e2e.spotify.AddPlaylist(playlist)
+
+	alice := e2e.newBrowser()
+	alice.loginAs("alice")
+	status, body := alice.postForm("/api/select_playlist", url.Values{
+		"playlist_url": {"spotify:playlist:" + playlist.ID},
+		"mode":         {tournament.ModeKnockout},
+		"group":        {"on"},
+	})
+	if status != http.StatusTemporaryRedirect {
+		t.Fatalf("creating the group session failed: %d %s", status, body)
+	}
+	var user APIUser
+	alice.json(http.MethodGet, "/api/v1/me", nil, &user, http.StatusOK)
+	var session APISession
+	alice.json(http.MethodGet, fmt.Sprintf("/api/v1/sessions/%d", *user.CurrentSession), nil, &session, http.StatusOK)
+
+	bob := e2e.newBrowser()
+	bob.loginAs("bob")
+	status, body = bob.postForm("/api/join_group_session", url.Values{"invite_code": {session.InviteCode}})
+	if status != http.StatusOK {
+		t.Fatalf("joining the group session failed: %d %s", status, body)
+	}
+	var group GroupStatus
+	if err := json.Unmarshal(body, &group); err != nil {
+		t.Fatalf("could not decode the group status: %v: %s", err, body)
+	}
+	if group.InviteCode != session.InviteCode || group.Members != 2 {
+		t.Errorf("expected %s with 2 members, got %+v", session.InviteCode, group)
+	}
+}
+
+// only the current pair of a session can be decided
+func TestE2EDecideOnlyTheCurrentPair(t *testing.T) {
+	e2e := newE2EApp(t)
+	e2e.spotify.AddUser("alice", "Alice")
+	playlist := e2eTestPlaylist()
+	e2e.spotify.AddPlaylist(playlist)
+
+	alice := e2e.newBrowser()
+	alice.loginAs("alice")
+	alice.json(http.MethodPost, "/api/v1/playlists", APINewPlaylist{Url: "spotify:playlist:" + playlist.ID}, nil, http.StatusCreated)
+	var session APISession
+	alice.json(http.MethodPost, "/api/v1/sessions", APINewSession{Playlist: playlist.ID, Mode: tournament.ModeKnockout}, &session, http.StatusCreated)
+	sessionPath := fmt.Sprintf("/api/v1/sessions/%d", session.ID)
+
+	var state APISessionState
+	alice.json(http.MethodGet, sessionPath+"/pair", nil, &state, http.StatusOK)
+	first, second := state.Pair[0].ID, state.Pair[1].ID
+
+	var apiErr APIError
+	alice.json(http.MethodPost, sessionPath+"/matches", APINewMatch{Winner: first, Loser: "made-up", Outcome: tournament.OutcomeWin}, &apiErr, http.StatusConflict)
+	alice.json(http.MethodPost, sessionPath+"/matches", APINewMatch{Winner: second, Loser: first, Outcome: tournament.OutcomeWin}, &state, http.StatusOK)
+	// the pair is outdated now
+	alice.json(http.MethodPost, sessionPath+"/matches", APINewMatch{Winner: first, Loser: second, Outcome: tournament.OutcomeWin}, &apiErr, http.StatusConflict)
+
+	var matches []APIMatch
+	alice.json(http.MethodGet, sessionPath+"/matches", nil, &matches, http.StatusOK)
+	if len(matches) != 1 || matches[0].Winner != second {
+		t.Errorf("expected only the win of %s, got %+v", second, matches)
+	}
+}
+
+// imported items get distinct ids which survive JSON, and are shared without being overwritten
+func TestE2EImportedItems(t *testing.T) {
+	e2e := newE2EApp(t)
+	e2e.spotify.AddUser("alice", "Alice")
+	e2e.spotify.AddUser("bob", "Bob")
+
+	alice := e2e.newBrowser()
+	alice.loginAs("alice")
+	playlist := alice.importPlaylist("classics.csv", `title,artists,image
+Piano Concerto No. 21 in C Major: I. Allegro maestoso,Mozart,https://example.com/alice.png
+Piano Concerto No. 21 in C Major: II. Andante,Mozart,
+夜に駆ける,YOASOBI,
+`)
+
+	itemIds, err := e2e.app.queries.GetItemIdsForPlaylist(context.Background(), playlist.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(itemIds) != 3 {
+		t.Fatalf("expected 3 distinct items, got %v", itemIds)
+	}
+	for _, id := range itemIds {
+		if encoded, _ := json.Marshal(id); string(encoded) != `"`+id+`"` || len(id) != 22 {
+			t.Errorf("item id %q does not survive JSON or is not 22 characters long", id)
+		}
+	}
+
+	bob := e2e.newBrowser()
+	bob.loginAs("bob")
+	bob.importPlaylist("mine.csv", `title,artists,image
+Piano Concerto No. 21 in C Major: I. Allegro maestoso,Mozart,https://example.com/bob.png
+`)
+	item, err := e2e.app.queries.GetPlaylistItem(context.Background(), localItemId("Piano Concerto No. 21 in C Major: I. Allegro maestoso", "Mozart"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if item.Image.String != "https://example.com/alice.png" {
+		t.Errorf("the import of bob changed the item in the playlist of alice: %+v", item)
+	}
+}
+
+// feeds are only fetched from the configured hosts, and never from the network of the server
+func TestE2EFeedPlaylistsAreRestricted(t *testing.T) {
+	feed := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("the feed on the loopback address was fetched")
+		w.Write([]byte(`{"name": "Feed", "items": [{"title": "Song"}]}`))
+	}))
+	t.Cleanup(feed.Close)
+	feedUrl := feed.URL + "/feed.json"
+
+	e2e := newE2EApp(t)
+	e2e.spotify.AddUser("alice", "Alice")
+	alice := e2e.newBrowser()
+	alice.login()
+
+	var apiErr APIError
+	alice.json(http.MethodPost, "/api/v1/playlists", APINewPlaylist{Url: feedUrl}, &apiErr, http.StatusBadRequest)
+	if !strings.Contains(apiErr.Error, "feed_hosts") {
+		t.Errorf("expected feeds to be disabled, got %q", apiErr.Error)
+	}
+
+	e2e.config.FeedHosts = []string{"feeds.example.com"}
+	e2e.start()
+	alice.json(http.MethodPost, "/api/v1/playlists", APINewPlaylist{Url: feedUrl}, &apiErr, http.StatusBadRequest)
+	if !strings.Contains(apiErr.Error, "not allowed") {
+		t.Errorf("expected the feed host to be rejected, got %q", apiErr.Error)
+	}
+
+	// even if the admin allows it
+	e2e.config.FeedHosts = []string{"127.0.0.1"}
+	e2e.start()
+	alice.json(http.MethodPost, "/api/v1/playlists", APINewPlaylist{Url: feedUrl}, &apiErr, http.StatusNotFound)
+	if !strings.Contains(apiErr.Error, "not public") {
+		t.Errorf("expected the loopback address to be rejected, got %q", apiErr.Error)
+	}
+}
+
+// deciding a group session ends it for all members
+func TestE2EGroupSessionDecided(t *testing.T) {
+	e2e := newE2EApp(t)
+	e2e.spotify.AddUser("alice", "Alice")
+	e2e.spotify.AddUser("bob", "Bob")
+	playlist := e2eTestPlaylist()
+	e2e.spotify.AddPlaylist(playlist)
+
+	alice := e2e.newBrowser()
+	alice.loginAs("alice")
+	alice.json(http.MethodPost, "/api/v1/playlists", APINewPlaylist{Url: "spotify:playlist:" + playlist.ID}, nil, http.StatusCreated)
+	var session APISession
+	alice.json(http.MethodPost, "/api/v1/sessions", APINewSession{Playlist: playlist.ID, Mode: tournament.ModeKnockout, Group: true}, &session, http.StatusCreated)
+
+	bob := e2e.newBrowser()
+	bob.loginAs("bob")
+	if status, body := bob.postForm("/api/join_group_session", url.Values{"invite_code": {session.InviteCode}}); status != http.StatusOK {
+		t.Fatalf("joining the group session failed: %d %s", status, body)
+	}
+
+	sessionPath := fmt.Sprintf("/api/v1/sessions/%d", session.ID)
+	var state APISessionState
+	alice.json(http.MethodGet, sessionPath+"/pair", nil, &state, http.StatusOK)
+	for matches := 0; state.Winner == nil; matches++ {
+		if matches > 100 {
+			t.Fatal("session did not end after 100 matches")
+		}
+		vote := APINewMatch{Winner: state.Pair[0].ID, Loser: state.Pair[1].ID, Outcome: tournament.OutcomeWin}
+		alice.json(http.MethodPost, sessionPath+"/matches", vote, nil, http.StatusOK)
+		bob.json(http.MethodPost, sessionPath+"/matches", vote, &state, http.StatusOK)
+	}
+
+	for name, browser := range map[string]*e2eBrowser{"alice": alice, "bob": bob} {
+		var user APIUser
+		browser.json(http.MethodGet, "/api/v1/me", nil, &user, http.StatusOK)
+		if user.CurrentSession != nil {
+			t.Errorf("%s is still in session %d after it was decided", name, *user.CurrentSession)
+		}
+	}
+}
+
+// apps share no state, even when they run in one process
+func TestE2ETwoAppsInOneProcess(t *testing.T) {
+	first, second := newE2EApp(t), newE2EApp(t)
+	first.spotify.AddUser("alice", "Alice")
+	second.spotify.AddUser("bob", "Bob")
+
+	first.newBrowser().login()
+	second.newBrowser().login()
+
+	if _, err := first.app.queries.GetUser(context.Background(), "bob"); err == nil {
+		t.Error("bob logged in at the second app, but is stored in the db of the first")
+	}
+	if _, ok := first.app.activeUsers.Load("bob"); ok {
+		t.Error("bob logged in at the second app, but is active in the first")
+	}
+	if _, ok := second.app.activeUsers.Load("alice"); ok {
+		t.Error("alice logged in at the first app, but is active in the second")
+	}
+}
+
+// many users logging in at once each get their own identity and spotify client
+// all browsers have the same ip, like users behind a NAT
+func TestE2EConcurrentLogins(t *testing.T) {
+	const users, browsersPerUser = 16, 2
+	e2e := newE2EApp(t)
+	for i := range users {
+		id := fmt.Sprintf("user%02d", i)
+		e2e.spotify.AddUser(id, "User "+id)
+		playlist := e2eTestPlaylist()
+		playlist.ID = "private" + id
+		playlist.Owner = id
+		e2e.spotify.AddPlaylist(playlist)
+	}
+
+	t.Run("logins", func(t *testing.T) {
+		for i := range users * browsersPerUser {
+			id := fmt.Sprintf("user%02d", i%users)
+			t.Run(fmt.Sprintf("browser%02d", i), func(t *testing.T) {
+				t.Parallel()
+				browser := e2e.newBrowserFor(t)
+				browser.loginAs(id)
+
+				var user APIUser
+				browser.json(http.MethodGet, "/api/v1/me", nil, &user, http.StatusOK)
+				if user.ID != id {
+					t.Fatalf("logged in as %s, but the app knows the browser as %s", id, user.ID)
+				}
+
+				// only the client of the user can see the private playlist
+				var added APIPlaylist
+				browser.json(http.MethodPost, "/api/v1/playlists", APINewPlaylist{
+					Url: "spotify:playlist:private" + id,
+				}, &added, http.StatusCreated)
+			})
+		}
+	})
+
+	for i := range users {
+		id := fmt.Sprintf("user%02d", i)
+		user, ok := e2e.app.activeUsers.Load(id)
+		if !ok {
+			t.Errorf("%s is not active after logging in", id)
+			continue
+		}
+		playlists, err := e2e.app.queries.GetPlaylistsForUser(context.Background(), id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(playlists) != 1 || playlists[0].ID != "private"+id {
+			t.Errorf("expected only the private playlist of %s to be added by them, got %v", id, playlists)
+		}
+		if user.spotify == nil {
+			t.Errorf("%s has no spotify client", id)
+		}
+	}
+}
+
+// refreshed tokens are stored, so the client of a user still works after a restart
+func TestE2ERefreshedTokenIsStored(t *testing.T) {
+	e2e := newE2EApp(t)
+	e2e.spotify.AddUser("carol", "Carol")
+	playlist := e2eTestPlaylist()
+	e2e.spotify.AddPlaylist(playlist)
+	// oauth2 refreshes tokens which expire within 10 seconds before using them
+	e2e.spotify.SetTokenLifetime(5 * time.Second)
+
+	browser := e2e.newBrowser()
+	browser.login()
+	loginToken, err := e2e.app.queries.GetSpotifyToken(context.Background(), "carol")
+	if err != nil {
+		t.Fatalf("spotify token of carol was not stored: %v", err)
+	}
+	refreshes := e2e.spotify.Requests("POST /api/token")
+
+	browser.json(http.MethodPost, "/api/v1/playlists", APINewPlaylist{
+		Url: "spotify:playlist:" + playlist.ID,
+	}, nil, http.StatusCreated)
+	if e2e.spotify.Requests("POST /api/token") == refreshes {
+		t.Fatal("the expiring token was not refreshed")
+	}
+
+	refreshedToken, err := e2e.app.queries.GetSpotifyToken(context.Background(), "carol")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if refreshedToken.AccessToken == loginToken.AccessToken {
+		t.Error("the refreshed token was not stored")
+	}
+
+	e2e.start()
+	browser.json(http.MethodPost, "/api/v1/playlists", APINewPlaylist{
+		Url: "spotify:playlist:" + playlist.ID,
+	}, nil, http.StatusCreated)
+}
+
+// only the browser which started a login can finish it, even if others have the same ip
+func TestE2ELoginStateBoundToSession(t *testing.T) {
+	e2e := newE2EApp(t)
+	e2e.spotify.AddUser("alice", "Alice")
+	e2e.spotify.AddUser("mallory", "Mallory")
+
+	alice, mallory := e2e.newBrowser(), e2e.newBrowser()
+	alice.useSpotifyUser("alice")
+	mallory.useSpotifyUser("mallory")
+	callback := alice.startLogin()
+	malloryCallback := mallory.startLogin()
+
+	status, body := mallory.get(strings.TrimPrefix(callback, e2e.server.URL))
+	if status != http.StatusForbidden {
+		t.Fatalf("another browser finished the login of alice: %d %s", status, body)
+	}
+	if _, ok := e2e.app.activeUsers.Load("alice"); ok {
+		t.Fatal("alice is active although their login was not finished by them")
+	}
+	// the own login of mallory is still pending
+	if status, body := mallory.get(strings.TrimPrefix(malloryCallback, e2e.server.URL)); status != http.StatusOK {
+		t.Fatalf("mallory could not finish their own login: %d %s", status, body)
+	}
+
+	status, body = alice.get(strings.TrimPrefix(callback, e2e.server.URL))
+	if status != http.StatusOK {
+		t.Fatalf("alice could not finish their login: %d %s", status, body)
+	}
+	var user APIUser
+	alice.json(http.MethodGet, "/api/v1/me", nil, &user, http.StatusOK)
+	if user.ID != "alice" {
+		t.Errorf("expected to be logged in as alice, got %s", user.ID)
+	}
+
+	// the state was used up
+	status, _ = alice.get(strings.TrimPrefix(callback, e2e.server.URL))
+	if status != http.StatusForbidden {
+		t.Errorf("the login of alice could be finished twice: %d", status)
+	}
+}
+
+// logins which are not finished in time are removed and the browser logs in again
+func TestE2EExpiredLogin(t *testing.T) {
+	e2e := newE2EApp(t)
+	e2e.spotify.AddUser("dave", "Dave")
+
+	browser := e2e.newBrowser()
+	callback := browser.startLogin()
+	if removed := e2e.app.removeExpiredLogins(time.Now()); removed != 0 {
+		t.Errorf("removed %d logins before they expired", removed)
+	}
+	if removed := e2e.app.removeExpiredLogins(time.Now().Add(e2e.config.LoginTimeout + time.Second)); removed != 1 {
+		t.Errorf("expected the expired login to be removed, removed %d", removed)
+	}
+
+	status, body := browser.get(strings.TrimPrefix(callback, e2e.server.URL))
+	if status != http.StatusForbidden {
+		t.Fatalf("an expired login was finished: %d %s", status, body)
+	}
+	browser.login()
+}
+
+// with PKCE the app logs in and refreshes tokens as a public client
+func TestE2ELoginWithoutClientSecret(t *testing.T) {
+	e2e := newE2EApp(t)
+	e2e.config.Spotify_client_secret = ""
+	e2e.start()
+	e2e.spotify.AddUser("erin", "Erin")
+	playlist := e2eTestPlaylist()
+	e2e.spotify.AddPlaylist(playlist)
+	// oauth2 refreshes tokens which expire within 10 seconds before using them
+	e2e.spotify.SetTokenLifetime(5 * time.Second)
+
+	browser := e2e.newBrowser()
+	browser.login()
+	refreshes := e2e.spotify.Requests("POST /api/token")
+
+	browser.json(http.MethodPost, "/api/v1/playlists", APINewPlaylist{
+		Url: "spotify:playlist:" + playlist.ID,
+	}, nil, http.StatusCreated)
+	if e2e.spotify.Requests("POST /api/token") == refreshes {
+		t.Error("the expiring token was not refreshed")
+	}
+}
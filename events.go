@@ -0,0 +1,225 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/bafto/FindFavouriteSong/db"
+	"github.com/gin-gonic/gin"
+)
+
+// the events are published after the transaction which caused them was committed
+// every event is sent to the subscribers of the session and to the user the session belongs to
+const (
+	event_pair   = "pair"   // SelectSongResponse, the pair to be decided next
+	event_status = "status" // GroupStatus, a member voted or joined
+	event_match  = "match"  // MatchEvent, a match was recorded
+	event_undo   = "undo"   // MatchEvent, a match was undone
+	event_round  = "round"  // RoundEvent, the session advanced to a new round
+	event_winner = "winner" // WinnerEvent, the session was decided
+)
+
+// keeps connections open through proxies which close idle connections
+const event_keepalive_interval = 30 * time.Second
+
+type MatchEvent struct {
+	Session int64  `json:"session"`
+	Round   int64  `json:"round"`
+	Winner  string `json:"winner"`
+	Loser   string `json:"loser"`
+	Outcome string `json:"outcome"`
+}
+
+type RoundEvent struct {
+	Session int64 `json:"session"`
+	Round   int64 `json:"round"`
+}
+
+type WinnerEvent struct {
+	Session int64  `json:"session"`
+	Winner  string `json:"winner"`
+	URL     string `json:"url"` // the page which shows the result
+}
+
+// an event which is pushed to everyone following a session
+type SessionEvent struct {
+	Name string
+	Data any
+}
+
+// distributes events to the subscribed clients
+type EventBroker[K comparable] struct {
+	mutex       sync.Mutex
+	subscribers map[K]map[chan SessionEvent]struct{}
+}
+
+func (b *EventBroker[K]) Subscribe(key K) chan SessionEvent {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	ch := make(chan SessionEvent, 16)
+	if b.subscribers[key] == nil {
+		b.subscribers[key] = map[chan SessionEvent]struct{}{}
+	}
+	b.subscribers[key][ch] = struct{}{}
+	return ch
+}
+
+func (b *EventBroker[K]) Unsubscribe(key K, ch chan SessionEvent) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	delete(b.subscribers[key], ch)
+	if len(b.subscribers[key]) == 0 {
+		delete(b.subscribers, key)
+	}
+}
+
+// never blocks, subscribers which don't keep up miss events
+func (b *EventBroker[K]) Publish(key K, event SessionEvent) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	for ch := range b.subscribers[key] {
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+}
+
+func (app *App) publishSessionEvent(session *db.Session, name string, data any) {
+	event := SessionEvent{Name: name, Data: data}
+	app.sessionEvents.Publish(session.ID, event)
+	app.userEvents.Publish(session.User, event)
+}
+
+// publishes the match if one was recorded and the round if it changed since previousRound
+func (app *App) publishProgress(session *db.Session, match *MatchEvent, previousRound int64) {
+	if match != nil {
+		app.publishSessionEvent(session, event_match, *match)
+	}
+	app.publishRound(session, previousRound)
+}
+
+// previousRound is the round before the match was undone
+func (app *App) publishUndo(session *db.Session, match db.Match, previousRound int64) {
+	app.publishSessionEvent(session, event_undo, MatchEvent{
+		Session: session.ID,
+		Round:   match.RoundNumber,
+		Winner:  match.Winner,
+		Loser:   match.Loser,
+		Outcome: match.Outcome,
+	})
+	app.publishRound(session, previousRound)
+}
+
+// only publishes if the round changed
+func (app *App) publishRound(session *db.Session, previousRound int64) {
+	if session.CurrentRound != previousRound {
+		app.publishSessionEvent(session, event_round, RoundEvent{Session: session.ID, Round: session.CurrentRound})
+	}
+}
+
+func (app *App) publishWinner(session *db.Session, winnerID string) {
+	app.publishSessionEvent(session, event_winner, WinnerEvent{
+		Session: session.ID,
+		Winner:  winnerID,
+		URL:     winnerURL(session, winnerID),
+	})
+}
+
+// streams the events of a session as server-sent events
+// the session query parameter defaults to the current session of the user
+func (app *App) sessionEventsHandler(c *gin.Context) {
+	logger := getLogger(c)
+
+	user, err := app.getActiveUser(c)
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, fmt.Errorf("failed to get activeUser, user not found: %w", err))
+		return
+	}
+
+	sessionID := user.CurrentSessionNotNull()
+	if sessionParam := c.Query("session"); sessionParam != "" {
+		sessionID, err = strconv.ParseInt(sessionParam, 10, 64)
+		if err != nil {
+			c.AbortWithError(http.StatusBadRequest, fmt.Errorf("session must be a valid number"))
+			return
+		}
+	}
+	if sessionID < 0 {
+		c.AbortWithError(http.StatusBadRequest, fmt.Errorf("no active session exists"))
+		return
+	}
+
+	session, err := app.queries.GetSession(c.Request.Context(), sessionID)
+	if err != nil {
+		c.AbortWithError(http.StatusNotFound, fmt.Errorf("session does not exist"))
+		return
+	}
+	// group members may watch the group session they are in
+	if !canAccessSession(user, &session) {
+		c.AbortWithError(http.StatusForbidden, fmt.Errorf("session does not belong to user"))
+		return
+	}
+
+	app.streamSessionEvents(c, logger.With("session-id", sessionID), sessionID)
+}
+
+// the owner of a session and the members of a group session who are currently in it
+func canAccessSession(user *ActiveUser, session *db.Session) bool {
+	return session.User == user.ID || user.CurrentSessionNotNull() == session.ID
+}
+
+func (app *App) streamSessionEvents(c *gin.Context, logger *slog.Logger, sessionID int64) {
+	events := app.sessionEvents.Subscribe(sessionID)
+	defer app.sessionEvents.Unsubscribe(sessionID, events)
+	logger.Debug("subscribed to session events")
+
+	streamEvents(c, events)
+	logger.Debug("unsubscribed from session events")
+}
+
+// streams the events of all sessions of the user as server-sent events
+func (app *App) userEventsHandler(c *gin.Context) {
+	logger := getLogger(c)
+
+	user, err := app.getActiveUser(c)
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, fmt.Errorf("failed to get activeUser, user not found: %w", err))
+		return
+	}
+
+	events := app.userEvents.Subscribe(user.ID)
+	defer app.userEvents.Unsubscribe(user.ID, events)
+	logger.Debug("subscribed to user events")
+
+	streamEvents(c, events)
+	logger.Debug("unsubscribed from user events")
+}
+
+// blocks until the client disconnects
+func streamEvents(c *gin.Context, events chan SessionEvent) {
+	keepalive := time.NewTicker(event_keepalive_interval)
+	defer keepalive.Stop()
+
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case event := <-events:
+			c.SSEvent(event.Name, event.Data)
+			return true
+		case <-keepalive.C:
+			// comments are ignored by EventSource
+			_, err := io.WriteString(w, ": keepalive\n\n")
+			return err == nil
+		case <-c.Request.Context().Done():
+			return false
+		}
+	})
+}
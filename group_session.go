@@ -0,0 +1,469 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/bafto/FindFavouriteSong/db"
+	"github.com/bafto/FindFavouriteSong/tournament"
+	"github.com/gin-gonic/gin"
+)
+
+// in a group session several users vote on the same pairs
+// a pair is decided by majority once every active member voted,
+// or by the present votes once the vote timeout of the pair passed
+
+const invite_code_length = 8
+
+type GroupStatus struct {
+	InviteCode string `json:"invite_code"`
+	Members    int    `json:"members"`
+	Votes      int    `json:"votes"`
+	Voted      bool   `json:"voted"`    // whether the requesting user voted on the current pair
+	Deadline   int64  `json:"deadline"` // unix milliseconds, after which the present votes decide the pair
+}
+
+// the result of progressGroupSession
+type groupProgress struct {
+	pairChanged   bool
+	match         *MatchEvent // the decision on the previous pair
+	previousRound int64
+	winner        *db.PlaylistItem
+}
+
+// helper function for prepareNewSession
+func createGroupSession(ctx context.Context, queries db.Store, sessionID int64, user string, voteTimeout time.Duration) (string, error) {
+	inviteCode := strings.ToUpper(generateState(invite_code_length))
+	if err := queries.AddGroupSession(ctx, db.AddGroupSessionParams{
+		Session:     sessionID,
+		InviteCode:  inviteCode,
+		VoteTimeout: int64(voteTimeout.Seconds()),
+	}); err != nil {
+		return "", fmt.Errorf("could not insert group session into db: %w", err)
+	}
+
+	if err := queries.AddGroupMember(ctx, db.AddGroupMemberParams{
+		Session: sessionID,
+		User:    user,
+	}); err != nil {
+		return "", fmt.Errorf("could not add user to group session: %w", err)
+	}
+	return inviteCode, nil
+}
+
+// responds with the GroupStatus of the joined session
+func (app *App) joinGroupSessionHandler(c *gin.Context) {
+	logger := getLogger(c)
+	user, err := app.getActiveUser(c)
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, fmt.Errorf("failed to get activeUser, user not found: %w", err))
+		return
+	}
+
+	if user.CurrentSession.Valid {
+		c.AbortWithError(http.StatusBadRequest, fmt.Errorf("active session already exists"))
+		return
+	}
+
+	inviteCode := strings.ToUpper(strings.TrimSpace(c.PostForm("invite_code")))
+	logger = logger.With("invite-code", inviteCode)
+
+	group, err := app.queries.GetGroupSessionByInviteCode(c.Request.Context(), inviteCode)
+	if errors.Is(err, sql.ErrNoRows) {
+		c.AbortWithError(http.StatusNotFound, fmt.Errorf("no group session with invite code %s", inviteCode))
+		return
+	}
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, fmt.Errorf("could not load group session from DB: %w", err))
+		return
+	}
+	logger = logger.With("session-id", group.Session)
+
+	// the members are counted by the votes, so they must not change while a vote is counted
+	// the transaction starts after the lock, so it sees the earlier requests to the session
+	unlock := app.lockSession(group.Session)
+	defer unlock()
+
+	tx, err := app.db.BeginTx(c.Request.Context(), nil)
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, fmt.Errorf("failed to create DB transaction: %w", err))
+		return
+	}
+	defer tx.Rollback()
+	queries := app.queries.WithTx(tx)
+
+	session, err := queries.GetSession(c.Request.Context(), group.Session)
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, fmt.Errorf("could not load session from DB: %w", err))
+		return
+	}
+	if session.Winner.Valid {
+		c.AbortWithError(http.StatusBadRequest, fmt.Errorf("group session is already decided"))
+		return
+	}
+
+	if err := queries.AddGroupMember(c.Request.Context(), db.AddGroupMemberParams{
+		Session: group.Session,
+		User:    user.ID,
+	}); err != nil {
+		c.AbortWithError(http.StatusInternalServerError, fmt.Errorf("could not add user to group session: %w", err))
+		return
+	}
+
+	if err := queries.SetUserSession(c.Request.Context(), db.SetUserSessionParams{
+		CurrentSession: sql.NullInt64{Int64: group.Session, Valid: true},
+		ID:             user.ID,
+	}); err != nil {
+		c.AbortWithError(http.StatusInternalServerError, fmt.Errorf("failed to set user session: %w", err))
+		return
+	}
+
+	status, err := getGroupStatus(c.Request.Context(), queries, group, "")
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
+	if status, err := commitTransaction(tx); err != nil {
+		c.AbortWithError(status, err)
+		return
+	}
+
+	user.CurrentSession = sql.NullInt64{Int64: group.Session, Valid: true}
+	logger.Info("user joined group session")
+
+	app.publishSessionEvent(&session, event_status, status)
+	c.JSON(http.StatusOK, status)
+}
+
+// helper function for playSession
+// the selection of the user is a vote on the current pair
+func (app *App) groupPlaySession(ctx context.Context, logger *slog.Logger, user *ActiveUser, tx *sql.Tx, queries db.Store, session *db.Session, group db.GroupSession, winnerID, loserID, outcome string) (SessionState, int, error) {
+	logger = logger.With("invite-code", group.InviteCode)
+
+	if winnerID != "" && loserID != "" {
+		// another member might have decided the pair in the meantime,
+		// then the user simply gets the new pair
+		if isGroupPair(group, winnerID, loserID) {
+			if err := queries.AddOrUpdateGroupVote(ctx, db.AddOrUpdateGroupVoteParams{
+				Session: session.ID,
+				User:    user.ID,
+				Winner:  winnerID,
+				Loser:   loserID,
+				Outcome: outcome,
+			}); err != nil {
+				return SessionState{}, http.StatusInternalServerError, fmt.Errorf("could not insert vote into db: %w", err)
+			}
+			logger.Debug("user voted")
+		} else {
+			logger.Debug("user voted on an outdated pair")
+		}
+	}
+
+	progress, err := progressGroupSession(ctx, logger, queries, session, &group)
+	if err != nil {
+		return SessionState{}, http.StatusInternalServerError, err
+	}
+
+	if progress.winner != nil {
+		if status, err := commitTransaction(tx); err != nil {
+			return SessionState{}, status, err
+		}
+		app.applyGroupProgress(logger, session, group, progress, SessionState{})
+		return SessionState{Round: session.CurrentRound, Winner: progress.winner}, -1, nil
+	}
+
+	state, err := getGroupState(ctx, queries, session, group)
+	if err != nil {
+		return SessionState{}, http.StatusInternalServerError, err
+	}
+	status, err := getGroupStatus(ctx, queries, group, user.ID)
+	if err != nil {
+		return SessionState{}, http.StatusInternalServerError, err
+	}
+
+	if status, err := commitTransaction(tx); err != nil {
+		return SessionState{}, status, err
+	}
+	app.applyGroupProgress(logger, session, group, progress, state)
+
+	// the published state must not be changed
+	state.Group = &status
+	return state, -1, nil
+}
+
+// decides the current pair if possible and chooses the next one
+// if there is no current pair yet, the first one is chosen
+func progressGroupSession(ctx context.Context, logger *slog.Logger, queries db.Store, session *db.Session, group *db.GroupSession) (groupProgress, error) {
+	progress := groupProgress{previousRound: session.CurrentRound}
+	if group.PairFirst.Valid {
+		votes, err := queries.GetGroupVotes(ctx, session.ID)
+		if err != nil {
+			return groupProgress{}, fmt.Errorf("could not load votes from DB: %w", err)
+		}
+		members, err := queries.GetActiveGroupMembers(ctx, session.ID)
+		if err != nil {
+			return groupProgress{}, fmt.Errorf("could not load group members from DB: %w", err)
+		}
+
+		if len(votes) == 0 || (len(votes) < len(members) && time.Now().Before(groupDeadline(*group))) {
+			return progress, nil
+		}
+
+		winnerID, loserID, outcome := tallyVotes(*group, votes)
+		logger.Debug("group decided pair", "winner-id", winnerID, "loser-id", loserID, "outcome", outcome, "votes", len(votes), "members", len(members))
+		if err := tournament.RecordMatch(ctx, queries, session, winnerID, loserID, outcome); err != nil {
+			return groupProgress{}, err
+		}
+		if err := queries.DeleteGroupVotes(ctx, session.ID); err != nil {
+			return groupProgress{}, fmt.Errorf("could not delete votes: %w", err)
+		}
+		progress.match = &MatchEvent{
+			Session: session.ID,
+			Round:   session.CurrentRound,
+			Winner:  winnerID,
+			Loser:   loserID,
+			Outcome: outcome,
+		}
+	}
+
+	pair, winner, err := nextPairOrRepair(ctx, logger, queries, session)
+	if err != nil {
+		return groupProgress{}, fmt.Errorf("error getting next pair: %w", err)
+	}
+
+	if winner != nil {
+		logger.Debug("found winner for group session", "winner", winner.ID)
+
+		if err := queries.ResetCurrentSessionForGroupMembers(ctx, sql.NullInt64{Int64: session.ID, Valid: true}); err != nil {
+			return groupProgress{}, fmt.Errorf("unable to reset current session in DB: %w", err)
+		}
+		progress.winner = winner
+		return progress, nil
+	}
+
+	if err := setGroupPair(ctx, queries, group, pair[0].ID, pair[1].ID); err != nil {
+		return groupProgress{}, err
+	}
+	progress.pairChanged = true
+	return progress, nil
+}
+
+func setGroupPair(ctx context.Context, queries db.Store, group *db.GroupSession, first, second string) error {
+	if err := queries.SetGroupPair(ctx, db.SetGroupPairParams{
+		PairFirst:  notNull(first),
+		PairSecond: notNull(second),
+		Session:    group.Session,
+	}); err != nil {
+		return fmt.Errorf("could not update group pair in DB: %w", err)
+	}
+	group.PairFirst = notNull(first)
+	group.PairSecond = notNull(second)
+	// CURRENT_TIMESTAMP only has a precision of seconds
+	group.PairStarted = sql.NullTime{Time: time.Now().UTC().Truncate(time.Second), Valid: true}
+	return nil
+}
+
+// pushes the progress to all members and schedules the timeout of a new pair
+// must be called after the transaction was committed
+func (app *App) applyGroupProgress(logger *slog.Logger, session *db.Session, group db.GroupSession, progress groupProgress, state SessionState) {
+	app.publishProgress(session, progress.match, progress.previousRound)
+
+	switch {
+	case progress.winner != nil:
+		app.publishWinner(session, progress.winner.ID)
+	case progress.pairChanged:
+		app.publishSessionEvent(session, event_pair, state.SelectSongResponse())
+		app.scheduleGroupTimeout(logger, session.ID, time.Duration(group.VoteTimeout)*time.Second)
+	default:
+		app.publishSessionEvent(session, event_status, state.Group)
+	}
+}
+
+// decides the pair with the present votes once its timeout passed
+// the members who did not vote yet are not waited for
+// a newer timeout replaces the one of the previous pair of the session
+// the timeouts are stopped by Close
+func (app *App) scheduleGroupTimeout(logger *slog.Logger, sessionID int64, timeout time.Duration) {
+	app.groupTimersMutex.Lock()
+	defer app.groupTimersMutex.Unlock()
+	if app.closed() {
+		return
+	}
+
+	if timer, ok := app.groupTimers[sessionID]; ok {
+		timer.Stop()
+	}
+	var timer *time.Timer
+	timer = time.AfterFunc(timeout, func() {
+		app.groupTimersMutex.Lock()
+		if app.closed() {
+			app.groupTimersMutex.Unlock()
+			return
+		}
+		if app.groupTimers[sessionID] == timer {
+			delete(app.groupTimers, sessionID)
+		}
+		app.groupTimeouts.Add(1)
+		app.groupTimersMutex.Unlock()
+		defer app.groupTimeouts.Done()
+
+		unlock := app.lockSession(sessionID)
+		defer unlock()
+
+		if err := app.decideTimedOutGroupPair(logger, sessionID); err != nil {
+			logger.Warn("could not decide group pair after timeout", "err", err)
+		}
+	})
+	app.groupTimers[sessionID] = timer
+}
+
+func (app *App) decideTimedOutGroupPair(logger *slog.Logger, sessionID int64) error {
+	ctx := context.Background()
+	tx, err := app.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create DB transaction: %w", err)
+	}
+	defer tx.Rollback()
+	queries := app.queries.WithTx(tx)
+
+	session, err := queries.GetSession(ctx, sessionID)
+	if err != nil {
+		return fmt.Errorf("could not load session from DB: %w", err)
+	}
+	group, err := queries.GetGroupSession(ctx, sessionID)
+	if err != nil {
+		return fmt.Errorf("could not load group session from DB: %w", err)
+	}
+	// the pair was already decided and a newer one is voted on
+	if session.Winner.Valid || time.Now().Before(groupDeadline(group)) {
+		return nil
+	}
+
+	progress, err := progressGroupSession(ctx, logger, queries, &session, &group)
+	if err != nil {
+		return err
+	}
+	// nobody voted, the first vote will decide the pair
+	if !progress.pairChanged && progress.winner == nil {
+		return nil
+	}
+
+	var state SessionState
+	if progress.winner == nil {
+		if state, err = getGroupState(ctx, queries, &session, group); err != nil {
+			return err
+		}
+	}
+
+	if _, err := commitTransaction(tx); err != nil {
+		return err
+	}
+	logger.Debug("decided group pair after timeout")
+	app.applyGroupProgress(logger, &session, group, progress, state)
+	return nil
+}
+
+// the time after which the present votes decide the current pair
+func groupDeadline(group db.GroupSession) time.Time {
+	return group.PairStarted.Time.Add(time.Duration(group.VoteTimeout) * time.Second)
+}
+
+func isGroupPair(group db.GroupSession, a, b string) bool {
+	first, second := group.PairFirst.String, group.PairSecond.String
+	return (a == first && b == second) || (a == second && b == first)
+}
+
+// the option with the most votes decides the pair
+// a draw between several options is a tie
+func tallyVotes(group db.GroupSession, votes []db.GroupVote) (winnerID, loserID, outcome string) {
+	type option struct{ winner, loser, outcome string }
+
+	counts := map[option]int{}
+	for _, vote := range votes {
+		o := option{vote.Winner, vote.Loser, vote.Outcome}
+		// ties and skips don't depend on the order of the pair
+		if vote.Outcome != tournament.OutcomeWin {
+			o = option{group.PairFirst.String, group.PairSecond.String, vote.Outcome}
+		}
+		counts[o]++
+	}
+
+	best, bestCount, draw := option{}, 0, false
+	for o, count := range counts {
+		switch {
+		case count > bestCount:
+			best, bestCount, draw = o, count, false
+		case count == bestCount:
+			draw = true
+		}
+	}
+
+	if draw {
+		return group.PairFirst.String, group.PairSecond.String, tournament.OutcomeTie
+	}
+	return best.winner, best.loser, best.outcome
+}
+
+// the current pair of the group session as it is pushed to all members
+func getGroupState(ctx context.Context, queries db.Store, session *db.Session, group db.GroupSession) (SessionState, error) {
+	first, err := queries.GetPlaylistItem(ctx, group.PairFirst.String)
+	if err != nil {
+		return SessionState{}, fmt.Errorf("could not load pair from DB: %w", err)
+	}
+	second, err := queries.GetPlaylistItem(ctx, group.PairSecond.String)
+	if err != nil {
+		return SessionState{}, fmt.Errorf("could not load pair from DB: %w", err)
+	}
+
+	matchesCount, err := queries.CountMatchesForRound(ctx, db.CountMatchesForRoundParams{
+		Session:     session.ID,
+		RoundNumber: session.CurrentRound,
+	})
+	if err != nil {
+		return SessionState{}, fmt.Errorf("could not retrieve number of matches: %w", err)
+	}
+
+	status, err := getGroupStatus(ctx, queries, group, "")
+	if err != nil {
+		return SessionState{}, err
+	}
+
+	return SessionState{
+		Round:   session.CurrentRound,
+		Matches: matchesCount,
+		Pair:    []db.PlaylistItem{first, second},
+		Group:   &status,
+	}, nil
+}
+
+// user may be empty, then Voted is always false
+func getGroupStatus(ctx context.Context, queries db.Store, group db.GroupSession, user string) (GroupStatus, error) {
+	members, err := queries.GetActiveGroupMembers(ctx, group.Session)
+	if err != nil {
+		return GroupStatus{}, fmt.Errorf("could not load group members from DB: %w", err)
+	}
+	votes, err := queries.GetGroupVotes(ctx, group.Session)
+	if err != nil {
+		return GroupStatus{}, fmt.Errorf("could not load votes from DB: %w", err)
+	}
+
+	status := GroupStatus{
+		InviteCode: group.InviteCode,
+		Members:    len(members),
+		Votes:      len(votes),
+		Deadline:   groupDeadline(group).UnixMilli(),
+	}
+	for _, vote := range votes {
+		if vote.User == user {
+			status.Voted = true
+		}
+	}
+	return status, nil
+}
@@ -3,15 +3,16 @@ package main
 import (
 	"log/slog"
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
-func healthcheckHandler(c *gin.Context) {
+func (app *App) healthcheckHandler(c *gin.Context) {
 	logger := getLogger(c)
 
 	logger.Debug("starting healthcheck")
-	healthcheckResult := performHealthcheck(logger)
+	healthcheckResult := app.performHealthcheck(logger)
 	logger.Debug("healthcheck done")
 
 	status := http.StatusOK
@@ -27,14 +28,15 @@ type DBHealthcheckResult struct {
 }
 
 type HealthcheckResult struct {
-	Healthy  bool                `json:"healthy"`
-	DBStatus DBHealthcheckResult `json:"db-status"`
+	Healthy      bool                `json:"healthy"`
+	DBStatus     DBHealthcheckResult `json:"db-status"`
+	BackupStatus *BackupStatus       `json:"backup-status,omitempty"` // only set if scheduled backups are enabled
 }
 
-func performHealthcheck(logger *slog.Logger) (result HealthcheckResult) {
+func (app *App) performHealthcheck(logger *slog.Logger) (result HealthcheckResult) {
 	result.Healthy = true
 
-	if err := db_conn.Ping(); err != nil {
+	if err := app.db.Ping(); err != nil {
 		logger.Error("healthcheck found the DB to be disconnected", "err", err.Error())
 		errstr := err.Error()
 
@@ -50,5 +52,14 @@ func performHealthcheck(logger *slog.Logger) (result HealthcheckResult) {
 		}
 	}
 
+	// failing backups don't make the app unhealthy, restarting it wouldn't help
+	if scheduled_backups_enabled(app.config) {
+		backupStatus := app.getBackupStatus(time.Now())
+		if !backupStatus.Healthy && backupStatus.Error != nil {
+			logger.Warn("healthcheck found the backups to be failing", "err", *backupStatus.Error)
+		}
+		result.BackupStatus = &backupStatus
+	}
+
 	return result
 }
@@ -0,0 +1,343 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/bafto/FindFavouriteSong/db"
+	"github.com/gin-gonic/gin"
+)
+
+// playlists can be imported from M3U, CSV or JSON files instead of spotify
+// their items are identified like local files in spotify playlists, by title and artists,
+// so the statistics of a song are shared between its playlists
+
+const (
+	imported_playlist_prefix = "local_" // spotify ids are base62 and never contain _
+	import_max_file_size     = 1 << 20
+	import_file_field        = "playlist_file"
+)
+
+type importedPlaylist struct {
+	name  string
+	items []importedItem
+}
+
+type importedItem struct {
+	Title   string `json:"title"`
+	Artists string `json:"artists"`
+	Image   string `json:"image"`
+}
+
+// the format of JSON playlist files, a plain array of items is accepted too
+type importedPlaylistJSON struct {
+	Name  string         `json:"name"`
+	Items []importedItem `json:"items"`
+}
+
+var utf8BOM = []byte("\uFEFF")
+
+func (app *App) importPlaylistHandler(c *gin.Context) {
+	logger, user, tx, queries, err := app.getLoggerUserTransactionQueries(c)
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+	defer tx.Rollback()
+
+	if _, status, err := importPlaylistFromForm(c, logger, user, queries); err != nil {
+		c.AbortWithError(status, err)
+		return
+	}
+
+	if status, err := commitTransaction(tx); err != nil {
+		c.AbortWithError(status, err)
+		return
+	}
+
+	// the imported playlist is listed on the start page
+	c.Redirect(http.StatusSeeOther, "/")
+}
+
+// helper function for importPlaylistHandler and apiImportPlaylistHandler
+// importing a file with the same name again replaces the items of the playlist
+// returns the id of the playlist
+func importPlaylistFromForm(c *gin.Context, logger *slog.Logger, user *ActiveUser, queries db.Store) (string, int, error) {
+	header, err := c.FormFile(import_file_field)
+	if err != nil {
+		return "", http.StatusBadRequest, fmt.Errorf("no playlist file uploaded: %w", err)
+	}
+	if header.Size > import_max_file_size {
+		return "", http.StatusRequestEntityTooLarge, fmt.Errorf("playlist file is larger than %d bytes", import_max_file_size)
+	}
+	logger = logger.With("playlist-file", header.Filename)
+
+	file, err := header.Open()
+	if err != nil {
+		return "", http.StatusInternalServerError, fmt.Errorf("could not open uploaded file: %w", err)
+	}
+	defer file.Close()
+
+	playlist, err := parsePlaylistFile(header.Filename, file)
+	if err != nil {
+		return "", http.StatusBadRequest, err
+	}
+	if name := strings.TrimSpace(c.PostForm("name")); name != "" {
+		playlist.name = name
+	}
+	logger.Debug("parsed playlist file", "name", playlist.name, "n-items", len(playlist.items))
+
+	sourcePlaylist, err := newSourcePlaylist(playlist.name, playlist.items)
+	if err != nil {
+		return "", http.StatusBadRequest, err
+	}
+
+	// the same name gives the same id, so a playlist can be updated by importing it again
+	playlistId := hashedPlaylistId(imported_playlist_prefix, user.ID+"\x00"+playlist.name)
+	if status, err := storePlaylist(c.Request.Context(), logger, user, queries, db.AddOrUpdatePlaylistParams{
+		ID:   playlistId,
+		Name: notNull(sourcePlaylist.Name),
+	}, sourcePlaylist.Items); err != nil {
+		return "", status, err
+	}
+	logger.Info("imported playlist", "playlist-id", playlistId)
+	return playlistId, -1, nil
+}
+
+func isImportedPlaylist(playlistId string) bool {
+	return strings.HasPrefix(playlistId, imported_playlist_prefix)
+}
+
+// items without a spotify id, like local files, are identified by their title and artists
+// the id is a hash of both, so it is valid in URLs and JSON and as long as a spotify id
+func localItemId(title, artists string) string {
+	hash := sha256.Sum256([]byte(title + "\x00" + artists))
+	return base64.RawURLEncoding.EncodeToString(hash[:])[:22]
+}
+
+// the format is chosen by the file extension, the name defaults to the file name
+func parsePlaylistFile(filename string, r io.Reader) (importedPlaylist, error) {
+	ext := filepath.Ext(filename)
+	playlist := importedPlaylist{name: strings.TrimSuffix(filepath.Base(filename), ext)}
+
+	var (
+		name string
+		err  error
+	)
+	switch strings.ToLower(ext) {
+	case ".m3u", ".m3u8":
+		name, playlist.items, err = parseM3U(r)
+	case ".csv":
+		playlist.items, err = parseCSV(r)
+	case ".json":
+		name, playlist.items, err = parseJSON(r)
+	default:
+		return playlist, fmt.Errorf("unsupported playlist file %s, expected .m3u, .m3u8, .csv or .json", filename)
+	}
+	if err != nil {
+		return playlist, fmt.Errorf("could not parse playlist file %s: %w", filename, err)
+	}
+	if name != "" {
+		playlist.name = name
+	}
+	return playlist, nil
+}
+
+// songs are named by the #EXTINF line before their path, or by the file name
+// both are expected to look like "Artists - Title"
+func parseM3U(r io.Reader) (string, []importedItem, error) {
+	var (
+		name   string
+		items  []importedItem
+		extinf string // the display name of the next path
+	)
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(strings.TrimPrefix(scanner.Text(), string(utf8BOM)))
+		switch {
+		case line == "":
+		case strings.HasPrefix(line, "#PLAYLIST:"):
+			name = strings.TrimSpace(strings.TrimPrefix(line, "#PLAYLIST:"))
+		case strings.HasPrefix(line, "#EXTINF:"):
+			// #EXTINF:<seconds> [attributes],<display name>
+			if _, displayName, ok := strings.Cut(line, ","); ok {
+				extinf = strings.TrimSpace(displayName)
+			}
+		case strings.HasPrefix(line, "#"):
+			// other directives and comments
+		default:
+			displayName := extinf
+			if displayName == "" {
+				displayName = m3uFileTitle(line)
+			}
+			extinf = ""
+
+			item := importedItem{Title: displayName}
+			if artists, title, ok := strings.Cut(displayName, " - "); ok {
+				item = importedItem{Title: strings.TrimSpace(title), Artists: strings.TrimSpace(artists)}
+			}
+			items = append(items, item)
+		}
+	}
+	return name, items, scanner.Err()
+}
+
+// the file name without extension of a path or url in a M3U file
+func m3uFileTitle(location string) string {
+	location = strings.ReplaceAll(location, "\\", "/")
+	// local paths may contain # or ?, only urls are parsed
+	if strings.Contains(location, "://") {
+		if parsed, err := url.Parse(location); err == nil {
+			location = parsed.Path
+		}
+	}
+	base := path.Base(location)
+	return strings.TrimSuffix(base, path.Ext(base))
+}
+
+// the columns are title, artists and image, a header row with these names may reorder them
+func parseCSV(r io.Reader) ([]importedItem, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	columns := map[string]int{"title": 0, "artists": 1, "image": 2}
+	if len(records) > 0 {
+		header := make([]string, len(records[0]))
+		for i, field := range records[0] {
+			header[i] = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(field, string(utf8BOM))))
+		}
+		if slices.Contains(header, "title") {
+			columns = map[string]int{}
+			for i, column := range header {
+				columns[column] = i
+			}
+			records = records[1:]
+		}
+	}
+
+	field := func(record []string, column string) string {
+		i, ok := columns[column]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	items := make([]importedItem, 0, len(records))
+	for _, record := range records {
+		items = append(items, importedItem{
+			Title:   field(record, "title"),
+			Artists: field(record, "artists"),
+			Image:   field(record, "image"),
+		})
+	}
+	return items, nil
+}
+
+func parseJSON(r io.Reader) (string, []importedItem, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return "", nil, err
+	}
+	data = bytes.TrimSpace(bytes.TrimPrefix(data, utf8BOM))
+
+	var playlist importedPlaylistJSON
+	if bytes.HasPrefix(data, []byte("[")) {
+		err = json.Unmarshal(data, &playlist.Items)
+	} else {
+		err = json.Unmarshal(data, &playlist)
+	}
+	if err != nil {
+		return "", nil, err
+	}
+
+	for i := range playlist.Items {
+		item := &playlist.Items[i]
+		item.Title, item.Artists, item.Image = strings.TrimSpace(item.Title), strings.TrimSpace(item.Artists), strings.TrimSpace(item.Image)
+	}
+	return strings.TrimSpace(playlist.Name), playlist.Items, nil
+}
+
+// helper function for addPlaylistToDB and importPlaylistFromForm
+// adds the playlist to the user and replaces its items
+func storePlaylist(ctx context.Context, logger *slog.Logger, user *ActiveUser, queries db.Store, playlist db.AddOrUpdatePlaylistParams, items []SourceItem) (int, error) {
+	if err := queries.AddOrUpdatePlaylist(ctx, playlist); err != nil {
+		return http.StatusInternalServerError, fmt.Errorf("could not insert playlist into db: %w", err)
+	}
+	logger.Debug("added playlist to db")
+	if err := queries.AddPlaylistAddedByUser(ctx, db.AddPlaylistAddedByUserParams{
+		User:     user.ID,
+		Playlist: playlist.ID,
+	}); err != nil {
+		return http.StatusInternalServerError, fmt.Errorf("could not insert playlist_added_by_user into db: %w", err)
+	}
+	logger.Debug("added playlist to user")
+
+	playlistItemsSet := map[string]struct{}{}
+	// add playlist items to DB
+	for _, item := range items {
+		var err error
+		if item.HasValidSpotifyID {
+			err = queries.AddOrUpdatePlaylistItem(ctx, item.toDB())
+		} else {
+			// the item is shared with everyone who added the same title and artists,
+			// so it must not be changed by the playlists of others
+			err = queries.AddPlaylistItemIfAbsent(ctx, db.AddPlaylistItemIfAbsentParams(item.toDB()))
+		}
+		if err != nil {
+			return http.StatusInternalServerError, fmt.Errorf("could not insert playlist item into db: %w", err)
+		}
+
+		if err := queries.AddPlaylistItemBelongsToPlaylist(ctx, db.AddPlaylistItemBelongsToPlaylistParams{
+			PlaylistItem: item.ID,
+			Playlist:     playlist.ID,
+		}); err != nil {
+			return http.StatusInternalServerError, fmt.Errorf("could not insert playlist_item_belongs_to_playlist into db: %w", err)
+		}
+
+		playlistItemsSet[item.ID] = struct{}{}
+	}
+	logger.Debug("added playlist items to db")
+
+	playlistItemIds, err := queries.GetItemIdsForPlaylist(ctx, playlist.ID)
+	if err != nil {
+		logger.Warn("Could not retrieve items for playlist from db: not deleting any items", "err", err, "playlist-id", playlist.ID)
+		return -1, nil
+	}
+
+	for _, item := range playlistItemIds {
+		if _, ok := playlistItemsSet[item]; ok {
+			continue
+		}
+
+		if err := queries.DeleteItemFromPlaylist(ctx, db.DeleteItemFromPlaylistParams{
+			Playlist:     playlist.ID,
+			PlaylistItem: item,
+		}); err != nil {
+			logger.Warn("Error deleting item from playlist_item_belongs_to_playlist", "err", err, "playlist-item-id", item, "playlist-id", playlist.ID)
+		}
+	}
+
+	return -1, nil
+}
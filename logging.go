@@ -9,10 +9,10 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-func configure_logging() {
+func configure_logging(logLevel string) {
 	var level slog.Level
 	if err := level.UnmarshalText(
-		[]byte(config.Log_level),
+		[]byte(logLevel),
 	); err != nil {
 		panic(err)
 	}
@@ -5,33 +5,39 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
-	"io"
 	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
 	"time"
 
 	"github.com/bafto/FindFavouriteSong/db"
 	"github.com/gin-contrib/sessions"
-	"github.com/gin-contrib/sessions/cookie"
 	"github.com/gin-gonic/gin"
-	"github.com/gorilla/securecookie"
-	"github.com/zmb3/spotify/v2"
-	spotifyauth "github.com/zmb3/spotify/v2/auth"
 )
 
 const (
-	session_name   = "ffs-session"
-	session_cookie = "ffs-session-cookie"
-	session_id_key = "ffs-user-id"
-	state_length   = 16
+	session_name    = "ffs-session"
+	session_cookie  = "ffs-session-cookie"
+	session_id_key  = "ffs-user-id"
+	active_user_key = "active-user"
+	state_length    = 16
 )
 
+// the user of a request
+// the row of the user is loaded for every request, as others change it too,
+// e.g. by deciding a group session, only the loggedInUser is kept between requests
 type ActiveUser struct {
 	db.User
-	client *spotify.Client
+	*loggedInUser
+}
+
+// what is kept of a user between requests, shared by all requests of the user
+type loggedInUser struct {
+	spotifyMutex sync.Mutex
+	spotify      *SpotifyUserClient // nil until the user needs it, see userSpotifyClient
 }
 
 func (user *ActiveUser) CurrentSessionNotNull() int64 {
@@ -42,7 +48,8 @@ func (user *ActiveUser) CurrentSessionNotNull() int64 {
 }
 
 // to be run concurrently
-func checkpoint_ticker(ctx context.Context, db *sql.DB) {
+func checkpoint_ticker(ctx context.Context, config Config, db *sql.DB) {
+	storage := new_storage_backend(config.Datasource)
 	ticker := time.Tick(config.CheckpointInterval)
 	for range ticker {
 		slog.Info("starting db checkpoint")
@@ -50,7 +57,7 @@ func checkpoint_ticker(ctx context.Context, db *sql.DB) {
 			ctx, cancel := context.WithTimeout(ctx, config.CheckpointTimeout)
 			defer cancel()
 
-			if err := checkpoint_db(ctx, db); err != nil {
+			if err := storage.checkpoint(ctx, db); err != nil {
 				slog.Error("failed to checkpoint db", "err", err)
 				return
 			}
@@ -59,110 +66,40 @@ func checkpoint_ticker(ctx context.Context, db *sql.DB) {
 	}
 }
 
-var (
-	config Config
-
-	ctx     = context.Background()
-	db_conn *sql.DB
-	queries *db.Queries
-
-	authKey       = securecookie.GenerateRandomKey(64)
-	encryptionKey = securecookie.GenerateRandomKey(32)
-	cookieStore   = cookie.NewStore(authKey, encryptionKey)
-
-	spotifyClient *spotify.Client
-	spotifyAuth   *spotifyauth.Authenticator
-	stateMap      = SyncMap[string, string]{}
-	activeUserMap = SyncMap[string, *ActiveUser]{}
-)
-
-func addMiddleware(r interface {
-	Use(middleware ...gin.HandlerFunc) gin.IRoutes
-}, auth bool,
-) {
-	r.Use(gin.Recovery())
-	r.Use(gin.LoggerWithConfig(gin.LoggerConfig{
-		Formatter: gin_log_formatter,
-		Output:    io.Discard,
-	}))
-	r.Use(SlogMiddleware())
-	if auth {
-		r.Use(gin.BasicAuth(config.Users))
-		r.Use(sessions.Sessions(session_name, cookieStore))
-		r.Use(SpotifyAuthMiddleware())
-	}
+func main() {
+	os.Exit(runCommand(os.Args[1:]))
 }
 
-func main() {
-	var err error
-	config, err = read_config()
-	if err != nil {
-		panic(err)
+// migrates the database and serves the app until SIGINT or SIGTERM
+func serve(config Config, args []string) error {
+	if len(args) != 0 {
+		return errUsage
 	}
-	configure_logging()
-
-	cookieStore.Options(sessions.Options{SameSite: http.SameSiteLaxMode})
-
-	spotifyAuth = spotifyauth.New(
-		spotifyauth.WithRedirectURL(config.Redirect_url),
-		spotifyauth.WithScopes(spotifyauth.ScopePlaylistReadPrivate, spotifyauth.ScopeUserReadPrivate),
-		spotifyauth.WithClientSecret(config.Spotify_client_secret),
-		spotifyauth.WithClientID(config.Spotify_client_id),
-	)
 
-	db_conn, err = create_db(ctx, config.Datasource)
+	ctx := context.Background()
+	conn, err := create_db(ctx, config.Datasource)
 	if err != nil {
-		slog.Error("Error opening DB connection", "err", err)
-		return
+		return fmt.Errorf("Error opening DB connection: %w", err)
 	}
-	defer db_conn.Close()
+	defer conn.Close()
 	slog.Info("Connected to database, migrating schema")
-	if err := migrate_db(ctx, db_conn); err != nil {
-		slog.Error("Error migrating db schema", "err", err)
-		return
+	if err := migrate_db(ctx, config, conn); err != nil {
+		return fmt.Errorf("Error migrating db schema: %w", err)
 	}
 
-	queries, err = db.Prepare(ctx, db_conn)
+	app, err := NewApp(config, conn, nil)
 	if err != nil {
-		slog.Error("failed to prepare DB queries", "err", err)
-		return
+		return err
 	}
-	defer queries.Close()
-
-	go checkpoint_ticker(ctx, db_conn)
-
-	r := gin.New()
-
-	r.LoadHTMLGlob("*.gohtml")
-	root := r.Group("/")
-	api := r.Group("/api")
-	health := api.Group("/health")
-
-	addMiddleware(root, true)
-	addMiddleware(api, true)
-	addMiddleware(health, false) // no auth for healthcheck
+	defer app.Close()
 
-	{
-		root.Static("/public", "./public")
-		root.GET("/", defaultHandler)
-		root.GET("/spotifyauthentication", authHandler)
-		root.GET("/select_song", selectSongPageHandler)
-		root.GET("/winner", winnerHandler)
-		root.GET("/stats", statsPageHandler)
-	}
-	{
-		api.POST("/select_playlist", selectPlaylistHandler)
-		api.POST("/select_session", selectSessionHandler)
-		api.POST("/select_song", selectSongHandler)
-		api.GET("/select_new_playlist", selectNewPlaylistHandler)
-		api.GET("/playlist_statistics", playlistStatisticsHandler)
-	}
-	{
-		health.GET("", healthcheckHandler)
-		health.HEAD("", healthcheckHandler)
+	go checkpoint_ticker(ctx, config, conn)
+	if scheduled_backups_enabled(config) {
+		app.updateBackupStatus(nil)
+		go app.backupTicker(app.done)
 	}
 
-	server := &http.Server{Addr: ":" + config.Port, Handler: r.Handler()}
+	server := &http.Server{Addr: ":" + config.Port, Handler: app.Router().Handler()}
 
 	go func() {
 		slog.Info("starting http server")
@@ -184,16 +121,17 @@ func main() {
 
 	if err := server.Shutdown(shutdownCtx); err != nil {
 		slog.Error("error while shutting down HTTP server, closing it forcefully", "err", err)
-		panic(errors.Join(err, server.Close()))
+		return errors.Join(err, server.Close())
 	}
 	slog.Info("server shutdown gracefully")
+	return nil
 }
 
-func defaultHandler(c *gin.Context) {
+func (app *App) defaultHandler(c *gin.Context) {
 	logger := getLogger(c)
 	logger.Debug("default handler!")
 
-	user, err := getActiveUser(c)
+	user, err := app.getActiveUser(c)
 	if err != nil {
 		c.AbortWithError(http.StatusInternalServerError, fmt.Errorf("error retrieving user: %w", err))
 		return
@@ -202,13 +140,13 @@ func defaultHandler(c *gin.Context) {
 	if !user.CurrentSession.Valid {
 		logger.Debug("user has no active session, displaying select_playlist.html")
 
-		playlists, err := queries.GetPlaylistsForUser(c, user.ID)
+		playlists, err := app.queries.GetPlaylistsForUser(c.Request.Context(), user.ID)
 		if err != nil {
 			c.AbortWithError(http.StatusInternalServerError, fmt.Errorf("Error loading playlist for user: %w", err))
 			return
 		}
 
-		sessions, err := queries.GetNonActiveUserSessions(c, db.GetNonActiveUserSessionsParams{
+		sessions, err := app.queries.GetNonActiveUserSessions(c.Request.Context(), db.GetNonActiveUserSessionsParams{
 			User:          user.ID,
 			Activesession: user.CurrentSessionNotNull(),
 		})
@@ -222,7 +160,7 @@ func defaultHandler(c *gin.Context) {
 
 		c.HTML(http.StatusOK, "select_playlist.gohtml", gin.H{
 			"Playlists": mapPlaylists(playlists),
-			"Sessions":  mapSessions(c, logger, sessions),
+			"Sessions":  app.mapSessions(c.Request.Context(), logger, sessions),
 		})
 		return
 	}
@@ -231,14 +169,14 @@ func defaultHandler(c *gin.Context) {
 	c.Redirect(http.StatusTemporaryRedirect, "/select_song")
 }
 
-func winnerHandler(c *gin.Context) {
+func (app *App) winnerHandler(c *gin.Context) {
 	winnerID := c.Query("winner")
 	if winnerID == "" {
 		c.AbortWithError(http.StatusBadRequest, fmt.Errorf("no winner provided in form"))
 		return
 	}
 
-	winnerItem, err := queries.GetPlaylistItem(c, winnerID)
+	winnerItem, err := app.queries.GetPlaylistItem(c.Request.Context(), winnerID)
 	if err != nil {
 		c.AbortWithError(http.StatusBadRequest, fmt.Errorf("winner not found in DB: %w", err))
 		return
@@ -251,33 +189,43 @@ func winnerHandler(c *gin.Context) {
 	})
 }
 
-func getActiveUser(c *gin.Context) (*ActiveUser, error) {
+// the user is loaded once per request, middlewares and the handler share it
+func (app *App) getActiveUser(c *gin.Context) (*ActiveUser, error) {
+	if user, ok := c.Get(active_user_key); ok {
+		return user.(*ActiveUser), nil
+	}
+
 	session := sessions.Default(c)
 	userID := session.Get(session_id_key)
 	if userID == nil {
 		return nil, fmt.Errorf("User ID not found in session")
 	}
-	user, ok := activeUserMap.Load(userID.(string))
-	if !ok {
-		return nil, fmt.Errorf("User not in activeuserMap")
+
+	dbUser, err := app.queries.GetUser(c.Request.Context(), userID.(string))
+	if err != nil {
+		return nil, fmt.Errorf("User not found in DB: %w", err)
 	}
+	// the user might have logged in before a restart
+	loggedIn, _ := app.activeUsers.LoadOrStore(dbUser.ID, &loggedInUser{})
+	user := &ActiveUser{User: dbUser, loggedInUser: loggedIn}
+	c.Set(active_user_key, user)
 	return user, nil
 }
 
-func getLoggerUserTransactionQueries(c *gin.Context) (*slog.Logger, *ActiveUser, *sql.Tx, *db.Queries, error) {
+func (app *App) getLoggerUserTransactionQueries(c *gin.Context) (*slog.Logger, *ActiveUser, *sql.Tx, db.Store, error) {
 	logger := getLogger(c)
 
-	user, err := getActiveUser(c)
+	user, err := app.getActiveUser(c)
 	if err != nil {
 		return logger, nil, nil, nil, fmt.Errorf("failed to get activeUser, user not found: %w", err)
 	}
 
-	tx, err := db_conn.BeginTx(c, nil)
+	tx, err := app.db.BeginTx(c.Request.Context(), nil)
 	if err != nil {
 		return logger, user, nil, nil, fmt.Errorf("failed to create DB transaction: %w", err)
 	}
 
-	return logger, user, tx, queries.WithTx(tx), nil
+	return logger, user, tx, app.queries.WithTx(tx), nil
 }
 
 func notNull(s string) sql.NullString {
@@ -305,10 +253,10 @@ type TemplateSession struct {
 	Playlist string
 }
 
-func mapSessions(ctx context.Context, logger *slog.Logger, sessions []db.Session) []TemplateSession {
+func (app *App) mapSessions(ctx context.Context, logger *slog.Logger, sessions []db.Session) []TemplateSession {
 	result := make([]TemplateSession, 0, len(sessions))
 	for _, session := range sessions {
-		playlist, err := queries.GetPlaylist(ctx, session.Playlist)
+		playlist, err := app.queries.GetPlaylist(ctx, session.Playlist)
 		if err != nil {
 			logger.Warn("could not get playlist from db", "err", err)
 			playlist.Name = notNull(session.Playlist)
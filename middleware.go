@@ -1,13 +1,23 @@
 package main
 
 import (
+	"crypto/subtle"
+	"fmt"
 	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 )
 
-const logger_key = "logger"
+const (
+	logger_key = "logger"
+	// sessions share these locks, so they don't grow with the sessions
+	// a handler must not hold the locks of two sessions at once
+	session_lock_stripes = 64
+)
 
 func getLogger(c *gin.Context, args ...any) *slog.Logger {
 	logger, ok := c.Get(logger_key)
@@ -32,3 +42,83 @@ func SlogMiddleware() gin.HandlerFunc {
 		c.Next()
 	}
 }
+
+// only lets users through whose spotify id is configured in admins
+func (app *App) AdminMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, err := app.getActiveUser(c)
+		if err != nil {
+			c.AbortWithError(http.StatusInternalServerError, fmt.Errorf("failed to get activeUser, user not found: %w", err))
+			return
+		}
+
+		if !slices.Contains(app.config.Admins, user.ID) {
+			getLogger(c).Warn("non admin tried to access admin endpoint", "user-id", user.ID)
+			c.AbortWithError(http.StatusForbidden, fmt.Errorf("user %s is not an admin", user.ID))
+			return
+		}
+		c.Next()
+	}
+}
+
+// like gin.BasicAuth followed by SpotifyAuthMiddleware, but API clients
+// get a JSON error instead of the login redirect
+// the user has to log in with spotify in the browser first, the session cookie is then valid for the API
+func (app *App) APIAuthMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		username, password, ok := c.Request.BasicAuth()
+		expected, exists := app.config.Users[username]
+		if !ok || !exists || subtle.ConstantTimeCompare([]byte(password), []byte(expected)) != 1 {
+			c.Header("WWW-Authenticate", `Basic realm="Authorization Required"`)
+			abortWithAPIError(c, http.StatusUnauthorized, fmt.Errorf("invalid basic auth credentials"))
+			return
+		}
+		c.Set(gin.AuthUserKey, username)
+
+		if _, err := app.getActiveUser(c); err != nil {
+			abortWithAPIError(c, http.StatusUnauthorized, fmt.Errorf("not logged in with spotify, log in at / first: %w", err))
+			return
+		}
+		c.Next()
+	}
+}
+
+// blocks until no one else holds the lock of session, or of a session sharing its stripe
+func (app *App) lockSession(session int64) (unlock func()) {
+	mutex := &app.sessionLocks[uint64(session)%session_lock_stripes]
+	mutex.Lock()
+	return mutex.Unlock
+}
+
+// serializes the requests to the session of the session path parameter,
+// or to the current session of the user if the route has none
+// the members of a group session share it, so its state has to be
+// read after all earlier requests to it were committed
+func (app *App) SessionLockMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// errors are reported by the handler
+		sessionID, ok := app.requestedSession(c)
+		if !ok {
+			c.Next()
+			return
+		}
+
+		unlock := app.lockSession(sessionID)
+		defer unlock()
+		c.Next()
+	}
+}
+
+// the session a request operates on, see SessionLockMiddleware
+func (app *App) requestedSession(c *gin.Context) (int64, bool) {
+	if param := c.Param("session"); param != "" {
+		sessionID, err := strconv.ParseInt(param, 10, 64)
+		return sessionID, err == nil
+	}
+
+	user, err := app.getActiveUser(c)
+	if err != nil || !user.CurrentSession.Valid {
+		return 0, false
+	}
+	return user.CurrentSession.Int64, true
+}
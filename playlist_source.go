@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/bafto/FindFavouriteSong/db"
+)
+
+// A PlaylistSource loads playlists from a service or file format
+// the source of a playlist is chosen by its url, see getPlaylistSource
+type PlaylistSource interface {
+	// the id of the playlist at playlistUrl, it must not change between fetches
+	// ids of other sources than spotify have a prefix, as they share the playlist table
+	PlaylistID(playlistUrl *url.URL) (string, error)
+	// the name and items of the playlist at playlistUrl
+	FetchPlaylist(ctx context.Context, user *ActiveUser, playlistUrl *url.URL) (SourcePlaylist, error)
+}
+
+type SourcePlaylist struct {
+	Name  string
+	Items []SourceItem
+}
+
+type SourceItem struct {
+	// items without a spotify id should use localItemId, so songs are shared between sources
+	ID                string
+	Title             string
+	Artists           string
+	Image             string // url of the artwork
+	HasValidSpotifyID bool
+}
+
+// spotify urls, spotify: uris and bare ids are spotify playlists,
+// other http(s) urls JSON feeds on the feed hosts and file: urls files in the playlist dir
+func (app *App) getPlaylistSource(playlistUrl string) (PlaylistSource, *url.URL, error) {
+	parsed, err := url.Parse(strings.TrimSpace(playlistUrl))
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid playlist url: %w", err)
+	}
+
+	switch {
+	case parsed.Scheme == "", parsed.Scheme == "spotify", strings.HasSuffix(parsed.Hostname(), "spotify.com"):
+		return spotifySource{userClient: app.userSpotifyClient}, parsed, nil
+	case parsed.Scheme == "http", parsed.Scheme == "https":
+		if len(app.config.FeedHosts) == 0 {
+			return nil, nil, fmt.Errorf("feed playlists are disabled, set feed_hosts to enable them")
+		}
+		source := jsonFeedSource{hosts: app.config.FeedHosts}
+		if !source.allows(parsed) {
+			return nil, nil, fmt.Errorf("feed host %s is not allowed", parsed.Hostname())
+		}
+		return source, parsed, nil
+	case parsed.Scheme == "file":
+		if app.config.PlaylistDir == "" {
+			return nil, nil, fmt.Errorf("file playlists are disabled, set playlist_dir to enable them")
+		}
+		return fileSource{dir: app.config.PlaylistDir}, parsed, nil
+	default:
+		return nil, nil, fmt.Errorf("unsupported playlist url scheme %s", parsed.Scheme)
+	}
+}
+
+// a playlist id of at most 22 characters, like spotify ids
+func hashedPlaylistId(prefix, key string) string {
+	hash := sha256.Sum256([]byte(key))
+	return prefix + hex.EncodeToString(hash[:])[:22-len(prefix)]
+}
+
+// helper function for sources of parsed playlist files
+// items without a title are skipped and identified by localItemId
+func newSourcePlaylist(name string, imported []importedItem) (SourcePlaylist, error) {
+	imported = slices.DeleteFunc(imported, func(item importedItem) bool {
+		return item.Title == ""
+	})
+	if len(imported) == 0 {
+		return SourcePlaylist{}, fmt.Errorf("no songs found in playlist %s", name)
+	}
+
+	items := make([]SourceItem, len(imported))
+	for i, item := range imported {
+		items[i] = SourceItem{
+			ID:      localItemId(item.Title, item.Artists),
+			Title:   item.Title,
+			Artists: item.Artists,
+			Image:   item.Image,
+		}
+	}
+	return SourcePlaylist{Name: name, Items: items}, nil
+}
+
+func (item SourceItem) toDB() db.AddOrUpdatePlaylistItemParams {
+	return db.AddOrUpdatePlaylistItemParams{
+		ID:                item.ID,
+		Title:             notNull(item.Title),
+		Artists:           notNull(item.Artists),
+		Image:             notNull(item.Image),
+		HasValidSpotifyID: int64(boolToInt(item.HasValidSpotifyID)),
+	}
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"path"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
+)
+
+const (
+	feed_playlist_prefix = "feed_"
+	feed_fetch_timeout   = 30 * time.Second
+	feed_max_redirects   = 10
+)
+
+// feeds are fetched without a proxy, so the dialer sees the address of the feed
+var feed_transport = &http.Transport{
+	DialContext: (&net.Dialer{
+		Timeout: feed_fetch_timeout,
+		Control: dial_public_only,
+	}).DialContext,
+	TLSHandshakeTimeout: feed_fetch_timeout,
+	MaxIdleConns:        10,
+	IdleConnTimeout:     90 * time.Second,
+}
+
+// playlists served as JSON, in the format of imported JSON files
+// only feeds on hosts are fetched, see getPlaylistSource
+type jsonFeedSource struct {
+	hosts []string
+}
+
+// the hosts are configured by the admin, but users choose the urls,
+// so the server must not be used to reach its own network
+func dial_public_only(network, address string, _ syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return fmt.Errorf("invalid feed address %s: %w", address, err)
+	}
+	if addr := addrPort.Addr().Unmap(); !addr.IsGlobalUnicast() || addr.IsPrivate() {
+		return fmt.Errorf("feed address %s is not public", addr)
+	}
+	return nil
+}
+
+// whether feeds on the host of feedUrl may be fetched
+func (source jsonFeedSource) allows(feedUrl *url.URL) bool {
+	return (feedUrl.Scheme == "http" || feedUrl.Scheme == "https") &&
+		slices.ContainsFunc(source.hosts, func(host string) bool {
+			return strings.EqualFold(host, feedUrl.Hostname())
+		})
+}
+
+func (source jsonFeedSource) client() *http.Client {
+	return &http.Client{
+		Transport: feed_transport,
+		Timeout:   feed_fetch_timeout,
+		// redirects must not leave the allowed hosts either
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= feed_max_redirects {
+				return errors.New("too many redirects")
+			}
+			if !source.allows(req.URL) {
+				return fmt.Errorf("redirect to feed host %s is not allowed", req.URL.Hostname())
+			}
+			return nil
+		},
+	}
+}
+
+func (jsonFeedSource) PlaylistID(playlistUrl *url.URL) (string, error) {
+	return hashedPlaylistId(feed_playlist_prefix, playlistUrl.String()), nil
+}
+
+func (source jsonFeedSource) FetchPlaylist(ctx context.Context, _ *ActiveUser, playlistUrl *url.URL) (SourcePlaylist, error) {
+	if !source.allows(playlistUrl) {
+		return SourcePlaylist{}, fmt.Errorf("feed host %s is not allowed", playlistUrl.Hostname())
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, feed_fetch_timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, playlistUrl.String(), nil)
+	if err != nil {
+		return SourcePlaylist{}, err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := source.client().Do(req)
+	if err != nil {
+		return SourcePlaylist{}, fmt.Errorf("could not fetch playlist feed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return SourcePlaylist{}, fmt.Errorf("could not fetch playlist feed: %s", resp.Status)
+	}
+
+	// one byte more than allowed to notice too large feeds
+	data, err := io.ReadAll(io.LimitReader(resp.Body, import_max_file_size+1))
+	if err != nil {
+		return SourcePlaylist{}, fmt.Errorf("could not read playlist feed: %w", err)
+	}
+	if len(data) > import_max_file_size {
+		return SourcePlaylist{}, fmt.Errorf("playlist feed is larger than %d bytes", import_max_file_size)
+	}
+
+	name, items, err := parseJSON(bytes.NewReader(data))
+	if err != nil {
+		return SourcePlaylist{}, fmt.Errorf("could not parse playlist feed: %w", err)
+	}
+	if name == "" {
+		name = strings.TrimSuffix(path.Base(playlistUrl.Path), path.Ext(playlistUrl.Path))
+	}
+	return newSourcePlaylist(name, items)
+}
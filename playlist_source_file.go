@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const file_playlist_prefix = "file_"
+
+// M3U, CSV or JSON files in dir, like uploaded ones
+// file:mix.m3u and file:///mix.m3u are dir/mix.m3u, paths can't leave dir
+type fileSource struct {
+	dir string
+}
+
+func (fileSource) PlaylistID(playlistUrl *url.URL) (string, error) {
+	name, err := filePlaylistName(playlistUrl)
+	if err != nil {
+		return "", err
+	}
+	return hashedPlaylistId(file_playlist_prefix, name), nil
+}
+
+func (source fileSource) FetchPlaylist(ctx context.Context, _ *ActiveUser, playlistUrl *url.URL) (SourcePlaylist, error) {
+	name, err := filePlaylistName(playlistUrl)
+	if err != nil {
+		return SourcePlaylist{}, err
+	}
+
+	file, err := os.Open(filepath.Join(source.dir, filepath.FromSlash(name)))
+	if err != nil {
+		// the error would contain the path of the playlist dir
+		return SourcePlaylist{}, fmt.Errorf("could not open playlist file %s", name)
+	}
+	defer file.Close()
+
+	playlist, err := parsePlaylistFile(name, file)
+	if err != nil {
+		return SourcePlaylist{}, err
+	}
+	return newSourcePlaylist(playlist.name, playlist.items)
+}
+
+// the cleaned path of the file relative to the playlist dir
+func filePlaylistName(playlistUrl *url.URL) (string, error) {
+	name := playlistUrl.Opaque
+	if name == "" {
+		name = playlistUrl.Path
+	}
+	// cleaning the rooted path removes all ..
+	name = strings.TrimPrefix(filepath.ToSlash(filepath.Clean("/"+name)), "/")
+	if name == "" {
+		return "", fmt.Errorf("no file in playlist url %s", playlistUrl)
+	}
+	return name, nil
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/zmb3/spotify/v2"
+)
+
+// playlists of the spotify web api, read with the client of the user
+type spotifySource struct {
+	userClient func(ctx context.Context, user *ActiveUser) (*spotify.Client, error)
+}
+
+// https://open.spotify.com/playlist/<id>, spotify:playlist:<id> or just the id
+func (spotifySource) PlaylistID(playlistUrl *url.URL) (string, error) {
+	var id string
+	if playlistUrl.Scheme == "spotify" {
+		id = playlistUrl.Opaque[strings.LastIndex(playlistUrl.Opaque, ":")+1:]
+	} else {
+		id = path.Base(playlistUrl.Path)
+	}
+
+	if id == "" || id == "." || id == "/" {
+		return "", fmt.Errorf("could not parse spotify id from playlist url %s", playlistUrl)
+	}
+	return id, nil
+}
+
+func (source spotifySource) FetchPlaylist(ctx context.Context, user *ActiveUser, playlistUrl *url.URL) (SourcePlaylist, error) {
+	playlistId, err := source.PlaylistID(playlistUrl)
+	if err != nil {
+		return SourcePlaylist{}, err
+	}
+
+	client, err := source.userClient(ctx, user)
+	if err != nil {
+		return SourcePlaylist{}, fmt.Errorf("could not create spotify client: %w", err)
+	}
+
+	// fetch playlist info
+	playlist, err := client.GetPlaylist(ctx, spotify.ID(playlistId))
+	if err != nil {
+		return SourcePlaylist{}, fmt.Errorf("could not load spotify playlist %s: %w", playlistId, err)
+	}
+
+	// fetch playlist items
+	playlistItems, err := getAllPlaylistItems(ctx, client, playlist.ID)
+	if err != nil {
+		return SourcePlaylist{}, fmt.Errorf("could not load songs from playlist: %w", err)
+	}
+
+	items := make([]SourceItem, len(playlistItems))
+	for i := range playlistItems {
+		it := &playlistItems[i]
+		items[i] = SourceItem{
+			ID:                string(it.Track.Track.ID),
+			Title:             it.Track.Track.Name,
+			Artists:           artistsToString(it.Track.Track.Artists),
+			Image:             getPlaylistItemImage(it),
+			HasValidSpotifyID: true,
+		}
+		// local files in spotify playlists have no id
+		if items[i].ID == "" {
+			items[i].ID = localItemId(items[i].Title, items[i].Artists)
+			items[i].HasValidSpotifyID = false
+		}
+	}
+
+	return SourcePlaylist{Name: playlist.Name, Items: items}, nil
+}
+
+func getAllPlaylistItems(ctx context.Context, client *spotify.Client, playlistId spotify.ID) ([]spotify.PlaylistItem, error) {
+	page, err := client.GetPlaylistItems(ctx, playlistId)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]spotify.PlaylistItem, 0, page.Total)
+	items = append(items, page.Items...)
+	for {
+		err = client.NextPage(ctx, page)
+		if err == spotify.ErrNoMorePages {
+			return items, nil
+		}
+		if err != nil {
+			return items, err
+		}
+		items = append(items, page.Items...)
+	}
+}
+
+func artistsToString(artists []spotify.SimpleArtist) string {
+	result := strings.Builder{}
+	for i, artist := range artists {
+		result.WriteString(artist.Name)
+		if i != len(artists)-1 {
+			result.WriteString(", ")
+		}
+	}
+	return result.String()
+}
+
+func getPlaylistItemImage(item *spotify.PlaylistItem) string {
+	img := ""
+	if len(item.Track.Track.Album.Images) > 0 {
+		img = item.Track.Track.Album.Images[0].URL
+	}
+	if len(item.Track.Track.Album.Images) > 1 {
+		img = item.Track.Track.Album.Images[1].URL
+	}
+	return img
+}
@@ -8,8 +8,8 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-func playlistStatisticsHandler(c *gin.Context) {
-	user, err := getActiveUser(c)
+func (app *App) playlistStatisticsHandler(c *gin.Context) {
+	user, err := app.getActiveUser(c)
 	if err != nil {
 		c.AbortWithError(http.StatusInternalServerError, fmt.Errorf("failed to get activeUser, user not found: %w", err))
 		return
@@ -21,7 +21,7 @@ func playlistStatisticsHandler(c *gin.Context) {
 		return
 	}
 
-	result, err := queries.GetStatistics1(c, db.GetStatistics1Params{
+	result, err := app.queries.GetStatistics1(c.Request.Context(), db.GetStatistics1Params{
 		User:     user.ID,
 		Playlist: playlistId,
 	})
@@ -13,6 +13,36 @@ const song2_svg_element = document.getElementById('song2_svg');
 const song2_title_element = document.getElementById('song2_title');
 const song2_artists_element = document.getElementById('song2_artists');
 
+const group_status_element = document.getElementById('group_status');
+let group_voted = false;
+
+// only called for group sessions
+function update_group_status(group) {
+	group_voted = group.voted;
+	const deadline = new Date(group.deadline).toLocaleTimeString();
+	let text = `Invite code: ${group.invite_code} | Votes: ${group.votes}/${group.members} | Decided at ${deadline} at the latest`;
+	if (group.voted) {
+		text += ' | Waiting for the others';
+	}
+	group_status_element.innerText = text;
+	group_status_element.removeAttribute('hidden');
+}
+
+// keeps other tabs, devices and the members of a group session in sync
+function subscribe_session_events() {
+	const session_events = new EventSource('/api/session_events');
+	session_events.addEventListener('pair', (e) => update_page(JSON.parse(e.data)));
+	session_events.addEventListener('status', (e) => {
+		const group = JSON.parse(e.data);
+		// the event is the same for everyone
+		group.voted = group_voted;
+		update_group_status(group);
+	});
+	session_events.addEventListener('winner', (e) => {
+		window.location.href = JSON.parse(e.data).url;
+	});
+}
+
 function update_page(resp) {
 	console.log('update page!');
 	console.log(resp);
@@ -45,14 +75,21 @@ function update_page(resp) {
 	}
 	song2_title_element.innerText = resp.song2_title;
 	song2_artists_element.innerText = resp.song2_artists;
+
+	if (resp.group) {
+		update_group_status(resp.group);
+	}
 }
 
-async function fetch_select_song(winner, loser) {
+async function fetch_select_song(winner, loser, outcome) {
 	console.log('fetch song');
 	let url = "/api/select_song";
 	if (winner || loser) {
 		url += `?winner=${winner}&loser=${loser}`;
 	}
+	if (outcome) {
+		url += `&outcome=${outcome}`;
+	}
 
 	const resp = await fetch(url, { method: 'POST' });
 
@@ -70,7 +107,7 @@ async function fetch_select_song(winner, loser) {
 
 document.addEventListener('DOMContentLoaded', async () => {
 	console.log('dom content loaded');
-	const resp = await fetch_select_song(null, null);
+	const resp = await fetch_select_song(null, null, null);
 
 	if (resp.error) {
 		console.error(resp.status, resp.error);
@@ -78,11 +115,12 @@ document.addEventListener('DOMContentLoaded', async () => {
 	}
 
 	update_page(resp);
+	subscribe_session_events();
 })
 
 async function select_song(element) {
 	console.log('select song');
-	const resp = await fetch_select_song(element.getAttribute('winner'), element.getAttribute('loser'))
+	const resp = await fetch_select_song(element.getAttribute('winner'), element.getAttribute('loser'), null)
 
 	if (resp.error) {
 		console.error(resp.status, resp.error);
@@ -92,6 +130,30 @@ async function select_song(element) {
 	update_page(resp);
 }
 
+// outcome is either 'tie' (both songs advance) or 'skip' (both songs are paired again later)
+async function select_outcome(outcome) {
+	console.log('select outcome', outcome);
+	const resp = await fetch_select_song(song1_btn_element.getAttribute('winner'), song1_btn_element.getAttribute('loser'), outcome)
+
+	if (resp.error) {
+		console.error(resp.status, resp.error);
+		return;
+	}
+
+	update_page(resp);
+}
+
+async function undo_match() {
+	console.log('undo match');
+	const resp = await fetch('/api/undo_match', { method: 'POST' }).catch(console.error);
+
+	if (!resp.ok) {
+		console.error('undo_match error', resp.status);
+		return;
+	}
+
+	update_page(await resp.json());
+}
 
 async function select_new_playlist() {
 	const resp = await fetch('/api/select_new_playlist', { method: 'GET' }).catch(console.error);
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/bafto/FindFavouriteSong/db"
+	"github.com/gin-gonic/gin"
+)
+
+func (app *App) rankingHandler(c *gin.Context) {
+	user, err := app.getActiveUser(c)
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, fmt.Errorf("failed to get activeUser, user not found: %w", err))
+		return
+	}
+
+	sessionId, err := strconv.Atoi(c.Query("session"))
+	if err != nil {
+		c.AbortWithError(http.StatusBadRequest, fmt.Errorf("session must be a valid number"))
+		return
+	}
+
+	session, err := app.queries.GetSession(c.Request.Context(), int64(sessionId))
+	if err != nil {
+		c.AbortWithError(http.StatusBadRequest, fmt.Errorf("session does not exist"))
+		return
+	}
+
+	if session.User != user.ID {
+		c.AbortWithError(http.StatusBadRequest, fmt.Errorf("session does not belong to user"))
+		return
+	}
+
+	ranking, err := app.queries.GetRankingForSession(c.Request.Context(), session.ID)
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, fmt.Errorf("failed to retreive ranking: %w", err))
+		return
+	}
+
+	c.HTML(http.StatusOK, "ranking.gohtml", mapRanking(ranking))
+}
+
+type TemplateRankingItem struct {
+	Position int64
+	Title    string
+	Artists  string
+	Image    string
+}
+
+func mapRanking(ranking []db.GetRankingForSessionRow) []TemplateRankingItem {
+	result := make([]TemplateRankingItem, len(ranking))
+	for i, item := range ranking {
+		result[i] = TemplateRankingItem{
+			Position: item.Position,
+			Title:    item.Title.String,
+			Artists:  item.Artists.String,
+			Image:    item.Image.String,
+		}
+	}
+	return result
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+
+	"github.com/bafto/FindFavouriteSong/db"
+	"github.com/bafto/FindFavouriteSong/tournament"
+	"github.com/gin-gonic/gin"
+)
+
+func (app *App) playlistRatingsHandler(c *gin.Context) {
+	user, err := app.getActiveUser(c)
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, fmt.Errorf("failed to get activeUser, user not found: %w", err))
+		return
+	}
+
+	playlistId := c.Query("playlist")
+	if playlistId == "" {
+		c.AbortWithError(http.StatusBadRequest, fmt.Errorf("no playlist given"))
+		return
+	}
+
+	result, err := app.queries.GetRatingLeaderboard(c.Request.Context(), db.GetRatingLeaderboardParams{
+		DefaultRating: tournament.DefaultRating,
+		User:          user.ID,
+		Playlist:      playlistId,
+	})
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, fmt.Errorf("failed to retreive ratings: %w", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, RatingLeaderboardToJson(result))
+}
+
+type GetRatingsJsonResult struct {
+	ID      string  `json:"id"`
+	Title   string  `json:"title"`
+	Artists string  `json:"artists"`
+	Image   string  `json:"image"`
+	Rating  float64 `json:"rating"`
+	Matches int64   `json:"matches"`
+}
+
+func RatingLeaderboardToJson(result []db.GetRatingLeaderboardRow) []GetRatingsJsonResult {
+	ret := make([]GetRatingsJsonResult, len(result))
+	for i, result := range result {
+		ret[i] = GetRatingsJsonResult{
+			ID:      result.ID,
+			Title:   result.Title.String,
+			Artists: result.Artists.String,
+			Image:   result.Image.String,
+			Rating:  math.Round(result.Rating),
+			Matches: result.Matches,
+		}
+	}
+	return ret
+}
@@ -13,8 +13,11 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-func selectNewPlaylistHandler(c *gin.Context) {
-	logger, user, tx, queries, err := getLoggerUserTransactionQueries(c)
+// the number of incomplete sessions a user may have besides the current one
+const max_incomplete_sessions = 3
+
+func (app *App) selectNewPlaylistHandler(c *gin.Context) {
+	logger, user, tx, queries, err := app.getLoggerUserTransactionQueries(c)
 	if err != nil {
 		c.AbortWithError(http.StatusInternalServerError, err)
 		return
@@ -36,31 +39,23 @@ func selectNewPlaylistHandler(c *gin.Context) {
 			return
 		}
 
-		deleteSession, err := queries.GetSession(c, int64(deleteId))
+		toDelete, err := queries.GetSession(c.Request.Context(), int64(deleteId))
 		if err != nil {
 			c.AbortWithError(http.StatusBadRequest, fmt.Errorf("delete must be a valid session id: %w", err))
 			return
 		}
 
-		logger.Debug("deleting incomplete session", "session-to-be-deleted", deleteSession.ID)
+		logger.Debug("deleting incomplete session", "session-to-be-deleted", toDelete.ID)
 
-		if err := queries.DeletePossibleNextItemsForSession(c, deleteSession.ID); err != nil {
-			c.AbortWithError(http.StatusInternalServerError, fmt.Errorf("failed to delete possible next items: %w", err))
-			return
-		}
-		if err := queries.DeleteMatchesForSession(c, deleteSession.ID); err != nil {
-			c.AbortWithError(http.StatusInternalServerError, fmt.Errorf("failed to delete matches: %w", err))
-			return
-		}
-		if err := queries.DeleteSession(c, deleteSession.ID); err != nil {
-			c.AbortWithError(http.StatusInternalServerError, fmt.Errorf("failed to delete session: %w", err))
+		if err := deleteSession(c.Request.Context(), queries, toDelete.ID); err != nil {
+			c.AbortWithError(http.StatusInternalServerError, err)
 			return
 		}
 
-		logger.Debug("deleted incomplete session", "deleted-session", deleteSession.ID)
+		logger.Debug("deleted incomplete session", "deleted-session", toDelete.ID)
 	}
 
-	sessions, err := queries.GetNonActiveUserSessions(c, db.GetNonActiveUserSessionsParams{
+	sessions, err := queries.GetNonActiveUserSessions(c.Request.Context(), db.GetNonActiveUserSessionsParams{
 		User:          user.ID,
 		Activesession: user.CurrentSessionNotNull(),
 	})
@@ -69,8 +64,8 @@ func selectNewPlaylistHandler(c *gin.Context) {
 		return
 	}
 
-	if len(sessions) < 3 {
-		if err := queries.SetUserSession(c, db.SetUserSessionParams{
+	if len(sessions) < max_incomplete_sessions {
+		if err := queries.SetUserSession(c.Request.Context(), db.SetUserSessionParams{
 			CurrentSession: sql.NullInt64{Valid: false},
 			ID:             user.ID,
 		}); err != nil {
@@ -90,7 +85,7 @@ func selectNewPlaylistHandler(c *gin.Context) {
 		return
 	}
 
-	incompleteSessions := mapIncompleteSessions(c, logger, queries, sessions)
+	incompleteSessions := mapIncompleteSessions(c.Request.Context(), logger, queries, sessions)
 
 	if err := tx.Commit(); err != nil {
 		c.AbortWithError(http.StatusInternalServerError, fmt.Errorf("failed to commit DB transaction: %w", err))
@@ -107,7 +102,7 @@ type IncompleteSession struct {
 	MatchesCompleted int64  `json:"matches_completed"`
 }
 
-func mapIncompleteSessions(ctx context.Context, logger *slog.Logger, queries *db.Queries, sessions []db.Session) []IncompleteSession {
+func mapIncompleteSessions(ctx context.Context, logger *slog.Logger, queries db.Store, sessions []db.Session) []IncompleteSession {
 	result := make([]IncompleteSession, 0, len(sessions))
 	for _, session := range sessions {
 		matches_completed, err := queries.GetNumberOfMatchesCompleted(ctx, session.ID)
@@ -131,3 +126,17 @@ func mapIncompleteSessions(ctx context.Context, logger *slog.Logger, queries *db
 	}
 	return result
 }
+
+// deletes the session with its matches and possible_next_items
+func deleteSession(ctx context.Context, queries db.Store, sessionID int64) error {
+	if err := queries.DeletePossibleNextItemsForSession(ctx, sessionID); err != nil {
+		return fmt.Errorf("failed to delete possible next items: %w", err)
+	}
+	if err := queries.DeleteMatchesForSession(ctx, sessionID); err != nil {
+		return fmt.Errorf("failed to delete matches: %w", err)
+	}
+	if err := queries.DeleteSession(ctx, sessionID); err != nil {
+		return fmt.Errorf("failed to delete session: %w", err)
+	}
+	return nil
+}
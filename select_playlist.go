@@ -5,18 +5,20 @@ import (
 	"database/sql"
 	"fmt"
 	"log/slog"
+	"math/rand/v2"
 	"net/http"
-	"net/url"
-	"path"
-	"strings"
+	"slices"
+	"sort"
+	"strconv"
+	"time"
 
 	"github.com/bafto/FindFavouriteSong/db"
+	"github.com/bafto/FindFavouriteSong/tournament"
 	"github.com/gin-gonic/gin"
-	"github.com/zmb3/spotify/v2"
 )
 
-func selectPlaylistHandler(c *gin.Context) {
-	logger, user, tx, queries, err := getLoggerUserTransactionQueries(c)
+func (app *App) selectPlaylistHandler(c *gin.Context) {
+	logger, user, tx, queries, err := app.getLoggerUserTransactionQueries(c)
 	if err != nil {
 		c.AbortWithError(http.StatusInternalServerError, err)
 		return
@@ -28,25 +30,69 @@ func selectPlaylistHandler(c *gin.Context) {
 		return
 	}
 
-	// parse playlist url
+	// imported playlists have no url and are selected by their id
 	playlistUrl := c.PostForm("playlist_url")
-	logger.Debug("User selected playlist", "playlist-url", playlistUrl)
+	playlistId := c.PostForm("playlist_id")
+	logger.Debug("User selected playlist", "playlist-url", playlistUrl, "playlist-id", playlistId)
 
-	playlistId, err := getPlaylistIdFromURL(playlistUrl)
-	if err != nil {
-		c.AbortWithError(http.StatusNotFound, fmt.Errorf("could not parse spotify id from playlist url: %w", err))
+	mode := c.DefaultPostForm("mode", tournament.ModeKnockout)
+	if _, err := tournament.ForMode(mode); err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
 		return
 	}
-	logger.Debug("parsed playlist id", "playlist-id", playlistId)
 
-	logger.Debug("adding playlist to DB")
-	if status, err := addPlaylistToDB(c, logger, user, queries, playlistId, playlistUrl); err != nil {
-		c.AbortWithError(status, err)
+	rounds, err := strconv.Atoi(c.DefaultPostForm("rounds", "0"))
+	if err != nil || rounds < 0 {
+		c.AbortWithError(http.StatusBadRequest, fmt.Errorf("rounds must be a positive number"))
 		return
 	}
 
+	seeded := c.PostForm("seeded") == "on"
+
+	// a fixed random seed reproduces the pairings of an earlier session
+	randomSeed := rand.Int64()
+	if seedParam := c.PostForm("random_seed"); seedParam != "" {
+		randomSeed, err = strconv.ParseInt(seedParam, 10, 64)
+		if err != nil {
+			c.AbortWithError(http.StatusBadRequest, fmt.Errorf("random_seed must be a valid number"))
+			return
+		}
+	}
+
+	// a vote timeout creates a group session
+	var groupVoteTimeout time.Duration
+	if c.PostForm("group") == "on" {
+		groupVoteTimeout = app.config.GroupVoteTimeout
+		if timeoutParam := c.PostForm("vote_timeout"); timeoutParam != "" {
+			seconds, err := strconv.Atoi(timeoutParam)
+			if err != nil || seconds <= 0 {
+				c.AbortWithError(http.StatusBadRequest, fmt.Errorf("vote_timeout must be a positive number"))
+				return
+			}
+			groupVoteTimeout = time.Duration(seconds) * time.Second
+		}
+	}
+
+	if isImportedPlaylist(playlistId) {
+		if status, err := checkPlaylistAddedByUser(c.Request.Context(), queries, user, playlistId); err != nil {
+			c.AbortWithError(status, err)
+			return
+		}
+	} else {
+		logger.Debug("adding playlist to DB")
+		var status int
+		if playlistId, status, err = app.addPlaylistToDB(c.Request.Context(), logger, user, queries, playlistUrl); err != nil {
+			c.AbortWithError(status, err)
+			return
+		}
+	}
+
 	logger.Debug("preparing new session")
-	if status, err := prepareNewSession(c, logger, user, queries, tx, playlistId); err != nil {
+	if status, err := prepareNewSession(c.Request.Context(), logger, user, queries, tx, playlistId, db.AddSessionParams{
+		Mode:       mode,
+		Rounds:     int64(rounds),
+		RandomSeed: randomSeed,
+	}, seeded, groupVoteTimeout); err != nil {
 		c.AbortWithError(status, err)
 		return
 	}
@@ -56,25 +102,34 @@ func selectPlaylistHandler(c *gin.Context) {
 }
 
 // helper function for selectPlaylistHandler
-func prepareNewSession(ctx context.Context, logger *slog.Logger, user *ActiveUser, queries *db.Queries, tx *sql.Tx, playlistId string) (int, error) {
+// the mode, rounds and random seed are taken from sessionParams
+// a groupVoteTimeout > 0 creates a group session
+func prepareNewSession(ctx context.Context, logger *slog.Logger, user *ActiveUser, queries db.Store, tx *sql.Tx, playlistId string, sessionParams db.AddSessionParams, seeded bool, groupVoteTimeout time.Duration) (int, error) {
+	var seeds []string
+	if seeded {
+		var err error
+		if seeds, err = getSeeds(ctx, user, queries, playlistId); err != nil {
+			return http.StatusInternalServerError, err
+		}
+	}
+
 	// create new session
-	sessionID, err := queries.AddSession(ctx, db.AddSessionParams{
-		Playlist: playlistId,
-		User:     user.ID,
-	})
+	sessionParams.Playlist = playlistId
+	sessionParams.User = user.ID
+	sessionID, err := tournament.CreateSession(ctx, queries, sessionParams, seeds)
 	if err != nil {
-		return http.StatusInternalServerError, fmt.Errorf("could not insert session into db: %w", err)
+		return http.StatusInternalServerError, err
 	}
 	logger = logger.With("session-id", sessionID)
-	logger.Debug("created new session")
+	logger.Debug("created new session", "mode", sessionParams.Mode, "rounds", sessionParams.Rounds, "random-seed", sessionParams.RandomSeed, "n-seeds", len(seeds))
 
-	if err := queries.InitializePossibleNextItemsForSession(ctx, db.InitializePossibleNextItemsForSessionParams{
-		Session:  sessionID,
-		Playlist: playlistId,
-	}); err != nil {
-		return http.StatusInternalServerError, fmt.Errorf("could not initialize possible_next_items: %w", err)
+	if groupVoteTimeout > 0 {
+		inviteCode, err := createGroupSession(ctx, queries, sessionID, user.ID, groupVoteTimeout)
+		if err != nil {
+			return http.StatusInternalServerError, err
+		}
+		logger.Debug("created group session", "invite-code", inviteCode, "vote-timeout", groupVoteTimeout)
 	}
-	logger.Debug("initialized possible_next_items")
 
 	// add new session to DB
 	if err = queries.SetUserSession(ctx, db.SetUserSessionParams{
@@ -94,140 +149,70 @@ func prepareNewSession(ctx context.Context, logger *slog.Logger, user *ActiveUse
 	return -1, nil
 }
 
-// helper function for selectPlaylistHandler
-func addPlaylistToDB(ctx context.Context, logger *slog.Logger, user *ActiveUser, queries *db.Queries, playlistId, playlistUrl string) (int, error) {
-	// fetch playlist info
-	playlist, err := user.client.GetPlaylist(ctx, spotify.ID(playlistId))
-	if err != nil {
-		return http.StatusNotFound, fmt.Errorf("could not parse spotify id from playlist url: %w", err)
-	}
-	logger = logger.With("playlist-id", playlist.ID)
-	logger.Debug("fetched playlist")
-
-	// add playlist to DB
-	if err := queries.AddOrUpdatePlaylist(ctx, db.AddOrUpdatePlaylistParams{
-		ID:   playlistId,
-		Name: notNull(playlist.Name),
-		Url:  notNull(playlistUrl),
-	}); err != nil {
-		return http.StatusInternalServerError, fmt.Errorf("could not insert playlist into db: %w", err)
-	}
-	logger.Debug("added playlist to db")
-	if err := queries.AddPlaylistAddedByUser(ctx, db.AddPlaylistAddedByUserParams{
+// helper function for prepareNewSession
+// orders the items of the playlist by the points they got in previous sessions of the user
+func getSeeds(ctx context.Context, user *ActiveUser, queries db.Store, playlistId string) ([]string, error) {
+	statistics, err := queries.GetStatistics1(ctx, db.GetStatistics1Params{
 		User:     user.ID,
 		Playlist: playlistId,
-	}); err != nil {
-		return http.StatusInternalServerError, fmt.Errorf("could not insert playlist_added_by_user into db: %w", err)
-	}
-	logger.Debug("added playlist to user")
-
-	// fetch playlist items
-	playlistItems, err := getAllPlaylistItems(ctx, user.client, playlist.ID)
+	})
 	if err != nil {
-		return http.StatusInternalServerError, fmt.Errorf("could not load songs from playlist: %w", err)
-	}
-	logger.Debug("fetched playlist items", "n-items", len(playlistItems))
-
-	playlistItemsSet := map[spotify.ID]struct{}{}
-	// add playlist items to DB
-	for i := range playlistItems {
-		it := &playlistItems[i]
-		has_valid_spotif_id := true
-		if it.Track.Track.ID == "" {
-			it.Track.Track.ID = spotify.ID(strings.ReplaceAll(it.Track.Track.Name+artistsToString(it.Track.Track.Artists), " ", "_"))[:22]
-			has_valid_spotif_id = false
-		}
-
-		if err := queries.AddOrUpdatePlaylistItem(ctx, db.AddOrUpdatePlaylistItemParams{
-			ID:                string(it.Track.Track.ID),
-			Title:             notNull(it.Track.Track.Name),
-			Artists:           notNull(artistsToString(it.Track.Track.Artists)),
-			Image:             notNull(getPlaylistItemImage(it)),
-			HasValidSpotifyID: int64(boolToInt(has_valid_spotif_id)),
-		}); err != nil {
-			return http.StatusInternalServerError, fmt.Errorf("could not insert playlist item into db: %w", err)
-		}
+		return nil, fmt.Errorf("could not load statistics for seeding: %w", err)
+	}
 
-		if err := queries.AddPlaylistItemBelongsToPlaylist(ctx, db.AddPlaylistItemBelongsToPlaylistParams{
-			PlaylistItem: string(it.Track.Track.ID),
-			Playlist:     playlistId,
-		}); err != nil {
-			return http.StatusInternalServerError, fmt.Errorf("could not insert playlist_item_belongs_to_playlist into db: %w", err)
-		}
+	sort.SliceStable(statistics, func(i, j int) bool {
+		return statistics[i].Points > statistics[j].Points
+	})
 
-		playlistItemsSet[it.Track.Track.ID] = struct{}{}
+	seeds := make([]string, len(statistics))
+	for i, item := range statistics {
+		seeds[i] = item.ID
 	}
-	logger.Debug("added playlist items to db")
+	return seeds, nil
+}
 
-	playlistItemIds, err := queries.GetItemIdsForPlaylist(ctx, playlistId)
+// helper function for selectPlaylistHandler and apiAddPlaylistHandler
+// loads the playlist from the source of its url, see getPlaylistSource
+// returns the id of the playlist
+func (app *App) addPlaylistToDB(ctx context.Context, logger *slog.Logger, user *ActiveUser, queries db.Store, playlistUrl string) (string, int, error) {
+	source, parsedUrl, err := app.getPlaylistSource(playlistUrl)
 	if err != nil {
-		logger.Warn("Could not retrieve items for playlist from db: not deleting any items", "err", err, "playlist-id", playlistId)
-		return -1, nil
+		return "", http.StatusBadRequest, err
 	}
 
-	for _, item := range playlistItemIds {
-		if _, ok := playlistItemsSet[spotify.ID(item)]; ok {
-			continue
-		}
-
-		if err := queries.DeleteItemFromPlaylist(ctx, db.DeleteItemFromPlaylistParams{
-			Playlist:     playlistId,
-			PlaylistItem: item,
-		}); err != nil {
-			logger.Warn("Error deleting item from playlist_item_belongs_to_playlist", "err", err, "playlist-item-id", item, "playlist-id", playlistId)
-		}
-	}
-
-	return -1, nil
-}
-
-func getPlaylistIdFromURL(playlistUrl string) (string, error) {
-	parsed, err := url.Parse(playlistUrl)
+	playlistId, err := source.PlaylistID(parsedUrl)
 	if err != nil {
-		return "", err
+		return "", http.StatusBadRequest, err
 	}
-	return path.Base(parsed.Path), nil
-}
+	logger = logger.With("playlist-id", playlistId)
+	logger.Debug("parsed playlist id")
 
-func getAllPlaylistItems(ctx context.Context, client *spotify.Client, playlistId spotify.ID) ([]spotify.PlaylistItem, error) {
-	page, err := client.GetPlaylistItems(ctx, playlistId)
+	playlist, err := source.FetchPlaylist(ctx, user, parsedUrl)
 	if err != nil {
-		return nil, err
-	}
-	items := make([]spotify.PlaylistItem, 0, page.Total)
-	items = append(items, page.Items...)
-	for {
-		err = client.NextPage(ctx, page)
-		if err == spotify.ErrNoMorePages {
-			return items, nil
-		}
-		if err != nil {
-			return items, err
-		}
-		items = append(items, page.Items...)
+		return "", http.StatusNotFound, fmt.Errorf("could not load playlist: %w", err)
 	}
-}
+	logger.Debug("fetched playlist", "n-items", len(playlist.Items))
 
-func artistsToString(artists []spotify.SimpleArtist) string {
-	result := strings.Builder{}
-	for i, artist := range artists {
-		result.WriteString(artist.Name)
-		if i != len(artists)-1 {
-			result.WriteString(", ")
-		}
+	if status, err := storePlaylist(ctx, logger, user, queries, db.AddOrUpdatePlaylistParams{
+		ID:   playlistId,
+		Name: notNull(playlist.Name),
+		Url:  notNull(playlistUrl),
+	}, playlist.Items); err != nil {
+		return "", status, err
 	}
-	return result.String()
+	return playlistId, -1, nil
 }
 
-func getPlaylistItemImage(item *spotify.PlaylistItem) string {
-	img := ""
-	if len(item.Track.Track.Album.Images) > 0 {
-		img = item.Track.Track.Album.Images[0].URL
+// imported playlists can't be added again from their url
+func checkPlaylistAddedByUser(ctx context.Context, queries db.Store, user *ActiveUser, playlistId string) (int, error) {
+	playlists, err := queries.GetPlaylistsForUser(ctx, user.ID)
+	if err != nil {
+		return http.StatusInternalServerError, fmt.Errorf("Error loading playlist for user: %w", err)
 	}
-	if len(item.Track.Track.Album.Images) > 1 {
-		img = item.Track.Track.Album.Images[1].URL
+	if !slices.ContainsFunc(playlists, func(playlist db.Playlist) bool { return playlist.ID == playlistId }) {
+		return http.StatusNotFound, fmt.Errorf("playlist %s was not added by the user", playlistId)
 	}
-	return img
+	return -1, nil
 }
 
 func boolToInt(b bool) int {
@@ -10,8 +10,8 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-func selectSessionHandler(c *gin.Context) {
-	logger, user, tx, queries, err := getLoggerUserTransactionQueries(c)
+func (app *App) selectSessionHandler(c *gin.Context) {
+	logger, user, tx, queries, err := app.getLoggerUserTransactionQueries(c)
 	if err != nil {
 		c.AbortWithError(http.StatusInternalServerError, err)
 		return
@@ -32,7 +32,7 @@ func selectSessionHandler(c *gin.Context) {
 		return
 	}
 
-	session, err := queries.GetSession(c, int64(sessionId))
+	session, err := queries.GetSession(c.Request.Context(), int64(sessionId))
 	if err != nil {
 		c.AbortWithError(http.StatusBadRequest, fmt.Errorf("session does not exist"))
 		return
@@ -43,7 +43,7 @@ func selectSessionHandler(c *gin.Context) {
 		return
 	}
 
-	if err := queries.SetUserSession(c, db.SetUserSessionParams{
+	if err := queries.SetUserSession(c.Request.Context(), db.SetUserSessionParams{
 		CurrentSession: sql.NullInt64{Int64: int64(sessionId), Valid: true},
 		ID:             user.ID,
 	}); err != nil {
@@ -1,17 +1,22 @@
 package main
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"log/slog"
 	"net/http"
 	"net/url"
+	"strconv"
 	"time"
 
 	"github.com/bafto/FindFavouriteSong/db"
+	"github.com/bafto/FindFavouriteSong/tournament"
 	"github.com/gin-gonic/gin"
 )
 
-func selectSongPageHandler(c *gin.Context) {
+func (app *App) selectSongPageHandler(c *gin.Context) {
 	c.HTML(http.StatusOK, "select_songs.gohtml", nil)
 }
 
@@ -26,12 +31,44 @@ type SelectSongResponse struct {
 	Song2_Artists string `json:"song2_artists"`
 	Song2_Image   string `json:"song2_image"`
 	Song2_ID      string `json:"song2_id"`
+
+	Group *GroupStatus `json:"group,omitempty"` // only set for group sessions
+}
+
+func newSelectSongResponse(round, matches int64, song1, song2 db.PlaylistItem) SelectSongResponse {
+	return SelectSongResponse{
+		Round:         int(round),
+		Matches:       int(matches),
+		Song1_Title:   song1.Title.String,
+		Song1_Artists: song1.Artists.String,
+		Song1_Image:   song1.Image.String,
+		Song1_ID:      song1.ID,
+		Song2_Title:   song2.Title.String,
+		Song2_Artists: song2.Artists.String,
+		Song2_Image:   song2.Image.String,
+		Song2_ID:      song2.ID,
+	}
+}
+
+// the state of a session after a selection
+type SessionState struct {
+	Round   int64
+	Matches int64             // matches played in the current round
+	Pair    []db.PlaylistItem // the pair to be decided next, nil if the session was decided
+	Winner  *db.PlaylistItem
+	Group   *GroupStatus // only set for group sessions
+}
+
+func (state SessionState) SelectSongResponse() SelectSongResponse {
+	response := newSelectSongResponse(state.Round, state.Matches, state.Pair[0], state.Pair[1])
+	response.Group = state.Group
+	return response
 }
 
-func selectSongHandler(c *gin.Context) {
+func (app *App) selectSongHandler(c *gin.Context) {
 	start := time.Now()
 
-	logger, user, tx, queries, err := getLoggerUserTransactionQueries(c)
+	logger, user, tx, queries, err := app.getLoggerUserTransactionQueries(c)
 	if err != nil {
 		c.AbortWithError(http.StatusInternalServerError, err)
 		return
@@ -43,99 +80,110 @@ func selectSongHandler(c *gin.Context) {
 	sessionID := user.CurrentSession.Int64
 	logger = logger.With("session-id", sessionID)
 
-	currentRound, err := queries.GetCurrentRound(c, sessionID)
+	session, err := queries.GetSession(c.Request.Context(), sessionID)
 	if err != nil {
-		c.AbortWithError(http.StatusInternalServerError, fmt.Errorf("could not determine current round number from DB: %w", err))
+		c.AbortWithError(http.StatusInternalServerError, fmt.Errorf("could not load session from DB: %w", err))
 		return
 	}
 
-	winnerID, loserID := c.Query("winner"), c.Query("loser")
 	// if we have both ids, we selected a song
 	// if one is missing we only retrieve the next pair
-	if winnerID != "" && loserID != "" {
-		logger = logger.With("winner-id", winnerID, "loser-id", loserID)
-		logger.Debug("user selected song")
+	state, status, err := app.playSession(c.Request.Context(), logger, user, tx, queries, &session, c.Query("winner"), c.Query("loser"), c.DefaultQuery("outcome", tournament.OutcomeWin))
+	if err != nil {
+		c.AbortWithError(status, err)
+		return
+	}
 
-		if err := queries.AddMatch(c, db.AddMatchParams{
-			Session:     sessionID,
-			RoundNumber: currentRound,
-			Winner:      winnerID,
-			Loser:       loserID,
-		}); err != nil {
-			c.AbortWithError(http.StatusInternalServerError, fmt.Errorf("could not create match in db: %w", err))
-			return
+	if state.Winner != nil {
+		logger.Debug("redirecting to winner page")
+		c.Redirect(http.StatusTemporaryRedirect, winnerURL(&session, state.Winner.ID))
+		return
+	}
+
+	c.JSON(http.StatusOK, state.SelectSongResponse())
+	logger.Debug("select_song done", "since-start", time.Since(start))
+}
+
+// records the decision of user on the current pair of session if winnerID and loserID are given
+// and determines the next pair, in group sessions the decision is a vote
+// commits tx and publishes the progress of the session
+func (app *App) playSession(ctx context.Context, logger *slog.Logger, user *ActiveUser, tx *sql.Tx, queries db.Store, session *db.Session, winnerID, loserID, outcome string) (SessionState, int, error) {
+	logger = logger.With("mode", session.Mode, "random-seed", session.RandomSeed)
+
+	selected := winnerID != "" && loserID != ""
+	if selected {
+		logger = logger.With("winner-id", winnerID, "loser-id", loserID, "outcome", outcome)
+		if !tournament.IsValidOutcome(session.Mode, outcome) {
+			return SessionState{}, http.StatusBadRequest, fmt.Errorf("invalid outcome %s in %s session", outcome, session.Mode)
 		}
+	}
 
-		logger.Debug("inserted match into db", "since-start", time.Since(start))
+	group, err := queries.GetGroupSession(ctx, session.ID)
+	if err == nil {
+		return app.groupPlaySession(ctx, logger, user, tx, queries, session, group, winnerID, loserID, outcome)
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return SessionState{}, http.StatusInternalServerError, fmt.Errorf("could not load group session from DB: %w", err)
 	}
 
-	nextPair, err := queries.GetNextPair(c, db.GetNextPairParams{
-		Session:      sessionID,
-		CurrentRound: currentRound,
-	})
+	if selected {
+		// clients might show an outdated pair, e.g. after a decision in another tab
+		pair, _, err := nextPairOrRepair(ctx, logger, queries, session)
+		if err != nil {
+			return SessionState{}, http.StatusInternalServerError, fmt.Errorf("error getting current pair: %w", err)
+		}
+		if !isPair(pair, winnerID, loserID) {
+			return SessionState{}, http.StatusConflict, fmt.Errorf("%s and %s are not the current pair of the session", winnerID, loserID)
+		}
+	}
+
+	previousRound := session.CurrentRound
+	var match *MatchEvent
+	if selected {
+		logger.Debug("user selected song")
+
+		if err := tournament.RecordMatch(ctx, queries, session, winnerID, loserID, outcome); err != nil {
+			return SessionState{}, http.StatusInternalServerError, err
+		}
+		match = &MatchEvent{
+			Session: session.ID,
+			Round:   previousRound,
+			Winner:  winnerID,
+			Loser:   loserID,
+			Outcome: outcome,
+		}
+		logger.Debug("inserted match into db")
+	}
+
+	nextPair, winner, err := nextPairOrRepair(ctx, logger, queries, session)
 	if err != nil {
-		c.AbortWithError(http.StatusInternalServerError, fmt.Errorf("error getting next pair from DB: %w", err))
-		return
+		return SessionState{}, http.StatusInternalServerError, fmt.Errorf("error getting next pair: %w", err)
 	}
 
-	// default case is 2 where we don't have to do anything
-	if len(nextPair) != 2 {
-		currentRound++
-		if err := queries.SetCurrentRound(c, db.SetCurrentRoundParams{
-			ID:           sessionID,
-			CurrentRound: currentRound,
+	if winner != nil {
+		logger.Debug("found winner for session", "winner", winner.ID)
+
+		if err := queries.SetUserSession(ctx, db.SetUserSessionParams{
+			ID:             user.ID,
+			CurrentSession: sql.NullInt64{Valid: false},
 		}); err != nil {
-			c.AbortWithError(http.StatusInternalServerError, fmt.Errorf("error updating current_round in DB: %w", err))
-			return
+			return SessionState{}, http.StatusInternalServerError, fmt.Errorf("unable to reset current session in DB: %w", err)
 		}
 
-		nextPair, err = queries.GetNextPair(c, db.GetNextPairParams{
-			Session:      sessionID,
-			CurrentRound: currentRound,
-		})
-		if err != nil {
-			c.AbortWithError(http.StatusInternalServerError, fmt.Errorf("error getting next pair from DB: %w", err))
-			return
+		if status, err := commitTransaction(tx); err != nil {
+			return SessionState{}, status, err
 		}
+		user.CurrentSession.Valid = false
+		logger.Debug("reset user session to NULL")
 
-		switch len(nextPair) {
-		case 0:
-			panic("unexpected pair length 0") // TODO: investiage error
-		case 1:
-			winnerID := nextPair[0].ID
-
-			logger.Debug("found winner for session, updating db", "winner", nextPair[0].ID)
-			if err := queries.SetWinner(c, db.SetWinnerParams{
-				Winner: notNull(winnerID),
-				ID:     sessionID,
-			}); err != nil {
-				c.AbortWithError(http.StatusInternalServerError, fmt.Errorf("failed to set winner in DB: %w", err))
-				return
-			}
-
-			if err := queries.SetUserSession(c, db.SetUserSessionParams{
-				ID:             user.ID,
-				CurrentSession: sql.NullInt64{Valid: false},
-			}); err != nil {
-				c.AbortWithError(http.StatusBadRequest, fmt.Errorf("unable to reset current session in DB: %w", err))
-			}
-
-			if status, err := commitTransaction(tx); err != nil {
-				c.AbortWithError(status, err)
-				return
-			}
-			user.CurrentSession.Valid = false
-			logger.Debug("reset user session to NULL")
-
-			logger.Debug("redirecting to /winner")
-			c.Redirect(http.StatusTemporaryRedirect, "/winner?winner="+url.QueryEscape(winnerID))
-			return
-		}
+		app.publishProgress(session, match, previousRound)
+		app.publishWinner(session, winner.ID)
+		return SessionState{Round: session.CurrentRound, Winner: winner}, -1, nil
 	}
 
-	matchesCount, err := queries.CountMatchesForRound(c, db.CountMatchesForRoundParams{
-		Session:     sessionID,
-		RoundNumber: currentRound,
+	matchesCount, err := queries.CountMatchesForRound(ctx, db.CountMatchesForRoundParams{
+		Session:     session.ID,
+		RoundNumber: session.CurrentRound,
 	})
 	if err != nil {
 		logger.Warn("could not retrieve number of matches", "err", err)
@@ -143,21 +191,42 @@ func selectSongHandler(c *gin.Context) {
 	}
 
 	if status, err := commitTransaction(tx); err != nil {
-		c.AbortWithError(status, err)
-		return
+		return SessionState{}, status, err
 	}
 
-	c.JSON(http.StatusOK, SelectSongResponse{
-		Round:         int(currentRound),
-		Matches:       int(matchesCount),
-		Song1_Title:   nextPair[0].Title.String,
-		Song1_Artists: nextPair[0].Artists.String,
-		Song1_Image:   nextPair[0].Image.String,
-		Song1_ID:      nextPair[0].ID,
-		Song2_Title:   nextPair[1].Title.String,
-		Song2_Artists: nextPair[1].Artists.String,
-		Song2_Image:   nextPair[1].Image.String,
-		Song2_ID:      nextPair[1].ID,
-	})
-	logger.Debug("select_song done", "since-start", time.Since(start))
+	state := SessionState{Round: session.CurrentRound, Matches: matchesCount, Pair: nextPair}
+	app.publishProgress(session, match, previousRound)
+	app.publishSessionEvent(session, event_pair, state.SelectSongResponse())
+	return state, -1, nil
+}
+
+// whether a and b are the items of pair, in any order
+func isPair(pair []db.PlaylistItem, a, b string) bool {
+	return len(pair) == 2 &&
+		((a == pair[0].ID && b == pair[1].ID) || (a == pair[1].ID && b == pair[0].ID))
+}
+
+// like tournament.NextPair, but broken sessions are repaired instead of failing
+func nextPairOrRepair(ctx context.Context, logger *slog.Logger, queries db.Store, session *db.Session) ([]db.PlaylistItem, *db.PlaylistItem, error) {
+	pair, winner, err := tournament.NextPair(ctx, queries, session)
+	if !errors.Is(err, tournament.ErrNoItemsLeft) {
+		return pair, winner, err
+	}
+
+	logger.Warn("session is broken, trying to repair it", "err", err)
+	problems, err := repairSession(ctx, queries, session)
+	if err != nil {
+		return nil, nil, fmt.Errorf("could not repair session: %w", err)
+	}
+	logger.Warn("repaired session", "problems", problems)
+
+	return tournament.NextPair(ctx, queries, session)
+}
+
+// the page which shows the result of a decided session
+func winnerURL(session *db.Session, winnerID string) string {
+	if session.Mode == tournament.ModeRanking {
+		return "/ranking?session=" + strconv.FormatInt(session.ID, 10)
+	}
+	return "/winner?winner=" + url.QueryEscape(winnerID)
 }
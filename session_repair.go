@@ -0,0 +1,42 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/bafto/FindFavouriteSong/db"
+	"github.com/bafto/FindFavouriteSong/tournament"
+)
+
+// detected and repaired by repairSession in addition to the problems of tournament.Repair
+const problem_finished_but_active = "the finished session is still the current session of its user"
+
+// checks session for broken states and repairs them
+// all changes are made through queries, so the caller decides whether
+// the repairs are kept by committing or rolling back its transaction
+// returns the problems that were found, all of them are repaired if err is nil
+func repairSession(ctx context.Context, queries db.Store, session *db.Session) ([]string, error) {
+	problems, err := tournament.Repair(ctx, queries, session)
+	if err != nil {
+		return problems, err
+	}
+
+	if session.Winner.Valid {
+		user, err := queries.GetUser(ctx, session.User)
+		if err != nil {
+			return problems, fmt.Errorf("could not load user of session from DB: %w", err)
+		}
+		if user.CurrentSession.Valid && user.CurrentSession.Int64 == session.ID {
+			problems = append(problems, problem_finished_but_active)
+			if err := queries.SetUserSession(ctx, db.SetUserSessionParams{
+				ID:             user.ID,
+				CurrentSession: sql.NullInt64{Valid: false},
+			}); err != nil {
+				return problems, fmt.Errorf("unable to reset current session in DB: %w", err)
+			}
+		}
+	}
+
+	return problems, nil
+}
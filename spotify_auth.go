@@ -2,32 +2,47 @@ package main
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
 	"fmt"
-	"math/rand"
+	"log/slog"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/gin-contrib/sessions"
 	"github.com/gin-gonic/gin"
-	"github.com/zmb3/spotify/v2"
+	spotifyauth "github.com/zmb3/spotify/v2/auth"
+	"golang.org/x/oauth2"
 )
 
 const (
 	session_present_key   = "ffs-session-present"
 	session_present_value = "present"
+	session_state_key     = "ffs-oauth-state"
 )
 
-func SpotifyAuthMiddleware() gin.HandlerFunc {
+// a spotify login which was started but not finished yet
+// the state is kept in the session of the browser, so only that browser can finish it
+type pendingLogin struct {
+	verifier string // PKCE code verifier
+	expires  time.Time
+}
+
+func (app *App) SpotifyAuthMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		s := sessions.Default(c)
 		if s.Get(session_present_key) != session_present_value {
 			logger := getLogger(c)
 
 			state := generateState(state_length)
-			stateMap.Store(c.ClientIP(), state)
-			authURL := spotifyAuth.AuthURL(state)
+			verifier := oauth2.GenerateVerifier()
+			app.logins.Store(state, pendingLogin{verifier: verifier, expires: time.Now().Add(app.config.LoginTimeout)})
+			authURL := app.spotifyOAuth.AuthCodeURL(state, oauth2.S256ChallengeOption(verifier))
 
 			s.Set(session_present_key, session_present_value)
+			s.Set(session_state_key, state)
 			if err := s.Save(); err != nil {
 				logger.Warn("failed to save session", "err", err, "session-id", s.ID())
 			}
@@ -41,33 +56,41 @@ func SpotifyAuthMiddleware() gin.HandlerFunc {
 	}
 }
 
-func authHandler(c *gin.Context) {
+func (app *App) authHandler(c *gin.Context) {
 	logger := getLogger(c)
 
-	ip := c.ClientIP()
 	logger.Info("got an auth request")
-	state, ok := stateMap.Load(ip)
-	if !ok {
-		c.AbortWithError(http.StatusForbidden, fmt.Errorf("no state for ip %s present", ip))
+	s := sessions.Default(c)
+	state, _ := s.Get(session_state_key).(string)
+	if state != "" && c.Query("state") != state {
+		// the login was started by another browser, the login of this browser stays pending
+		c.AbortWithError(http.StatusForbidden, errors.New("spotify redirect state parameter doesn't match the session"))
+		return
+	}
+	// a state can only be used once
+	login, ok := app.logins.LoadAndDelete(state)
+	if state == "" || !ok || time.Now().After(login.expires) {
+		// the next request starts a new login
+		s.Delete(session_present_key)
+		s.Delete(session_state_key)
+		if err := s.Save(); err != nil {
+			logger.Warn("failed to save session", "err", err, "session-id", s.ID())
+		}
+		c.AbortWithError(http.StatusForbidden, fmt.Errorf("no spotify login pending for this session, it might have expired"))
 		return
 	}
 
-	tok, err := spotifyAuth.Token(c, state, c.Request)
+	tok, err := app.spotifyToken(c, state, login.verifier)
 	if err != nil {
 		c.AbortWithError(http.StatusInternalServerError, fmt.Errorf("Couldn't get token: %w", err))
 		return
 	}
-	if st := c.Query("state"); st != state {
-		logger.Error("state mismatch", "expected", state, "got", st)
-		c.AbortWithError(http.StatusInternalServerError, fmt.Errorf("state mismatch: %w", err))
-		return
-	}
-	stateMap.Delete(ip)
 	logger.Debug("received spotify token with valid state")
 
-	spotifyClient = spotify.New(spotifyAuth.Client(context.Background(), tok), spotify.WithRetry(true))
-	logger.Debug("created spotify client")
-	userData, err := spotifyClient.CurrentUser(context.Background())
+	// the user is only known after the first request, so this client is local to the login
+	// and the client which stores refreshed tokens is created once the user is known
+	tokens := app.spotifyOAuth.TokenSource(context.Background(), tok)
+	userData, err := app.newSpotifyClient(tokens).CurrentUser(context.Background())
 	if err != nil {
 		c.AbortWithError(http.StatusInternalServerError, fmt.Errorf("unable to retrieve user info: %w", err))
 		return
@@ -75,18 +98,24 @@ func authHandler(c *gin.Context) {
 	logger = logger.With("user-id", userData.ID)
 	logger.Info("received user info")
 
-	tx, err := db_conn.BeginTx(c, nil)
+	// CurrentUser might have refreshed the token
+	if tok, err = tokens.Token(); err != nil {
+		c.AbortWithError(http.StatusInternalServerError, fmt.Errorf("Couldn't get token: %w", err))
+		return
+	}
+
+	tx, err := app.db.BeginTx(c.Request.Context(), nil)
 	if err != nil {
 		c.AbortWithError(http.StatusInternalServerError, fmt.Errorf("failed to create DB transaction: %w", err))
 		return
 	}
 	defer tx.Rollback()
-	queries := queries.WithTx(tx)
+	queries := app.queries.WithTx(tx)
 
-	user, err := queries.GetUser(c, userData.ID)
+	user, err := queries.GetUser(c.Request.Context(), userData.ID)
 	if err != nil {
 		logger.Debug("could not retrieve user from DB, adding him")
-		user, err = queries.AddUser(c, userData.ID)
+		user, err = queries.AddUser(c.Request.Context(), userData.ID)
 		if err != nil {
 			c.AbortWithError(http.StatusInternalServerError, fmt.Errorf("unable to load user info from db: %w", err))
 			return
@@ -94,14 +123,19 @@ func authHandler(c *gin.Context) {
 		logger.Info("successfully added user to DB")
 	}
 
+	if err := storeSpotifyToken(c.Request.Context(), queries, user.ID, tok); err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+
 	if err := tx.Commit(); err != nil {
 		c.AbortWithError(http.StatusInternalServerError, fmt.Errorf("failed to commit DB transaction: %w", err))
 		return
 	}
 
-	s := sessions.Default(c)
 	s.Set(session_id_key, user.ID)
-	activeUserMap.Store(userData.ID, &ActiveUser{client: spotifyClient, User: user})
+	s.Delete(session_state_key)
+	app.activeUsers.Store(user.ID, &loggedInUser{spotify: app.newSpotifyUserClient(user.ID, tok)})
 
 	if err := s.Save(); err != nil {
 		c.AbortWithError(http.StatusInternalServerError, fmt.Errorf("failed to save session: %w", err))
@@ -111,9 +145,77 @@ func authHandler(c *gin.Context) {
 	c.Redirect(http.StatusTemporaryRedirect, "/")
 }
 
+// like spotifyauth.Authenticator, but the accounts service can be configured
+// so tests can run against a fake one
+// the login uses PKCE, so without a client secret the app is a public client
+func newSpotifyOAuthConfig(config Config) *oauth2.Config {
+	accountsURL := strings.TrimSuffix(config.Spotify_accounts_url, "/")
+	authStyle := oauth2.AuthStyleAutoDetect
+	if config.Spotify_client_secret == "" {
+		// public clients send their id in the body
+		authStyle = oauth2.AuthStyleInParams
+	}
+	return &oauth2.Config{
+		ClientID:     config.Spotify_client_id,
+		ClientSecret: config.Spotify_client_secret,
+		RedirectURL:  config.Redirect_url,
+		Scopes:       []string{spotifyauth.ScopePlaylistReadPrivate, spotifyauth.ScopeUserReadPrivate},
+		Endpoint: oauth2.Endpoint{
+			AuthURL:   accountsURL + "/authorize",
+			TokenURL:  accountsURL + "/api/token",
+			AuthStyle: authStyle,
+		},
+	}
+}
+
+// exchanges the code of the redirect from the accounts service for a token
+// verifier is the PKCE code verifier of the login
+func (app *App) spotifyToken(c *gin.Context, state, verifier string) (*oauth2.Token, error) {
+	if authErr := c.Query("error"); authErr != "" {
+		return nil, fmt.Errorf("spotify auth failed: %s", authErr)
+	}
+	code := c.Query("code")
+	if code == "" {
+		return nil, errors.New("no access code in spotify redirect")
+	}
+	if c.Query("state") != state {
+		return nil, errors.New("spotify redirect state parameter doesn't match")
+	}
+	return app.spotifyOAuth.Exchange(c.Request.Context(), code, oauth2.VerifierOption(verifier))
+}
+
+// the state must not be guessable, otherwise a login could be forged
 func generateState(length int) string {
-	gen := rand.New(rand.NewSource(time.Now().UnixNano()))
-	b := make([]byte, length+2)
-	gen.Read(b)
-	return fmt.Sprintf("%x", b)[2 : length+2]
+	b := make([]byte, (length+1)/2)
+	rand.Read(b)
+	return hex.EncodeToString(b)[:length]
+}
+
+// removes the logins which were not finished in time, to be run concurrently
+// stops when done is closed
+func (app *App) sweepLogins(done <-chan struct{}) {
+	ticker := time.NewTicker(app.config.LoginTimeout)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case now := <-ticker.C:
+			if removed := app.removeExpiredLogins(now); removed > 0 {
+				slog.Debug("removed expired spotify logins", "removed", removed)
+			}
+		}
+	}
+}
+
+func (app *App) removeExpiredLogins(now time.Time) int {
+	removed := 0
+	app.logins.Range(func(state string, login pendingLogin) bool {
+		if now.After(login.expires) {
+			app.logins.Delete(state)
+			removed++
+		}
+		return true
+	})
+	return removed
 }
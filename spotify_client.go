@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+
+	"github.com/bafto/FindFavouriteSong/db"
+	"github.com/zmb3/spotify/v2"
+	"golang.org/x/oauth2"
+)
+
+// the spotify client of one user, kept on the ActiveUser
+// the token of the user is refreshed when it expires and the refreshed
+// token is stored in the DB, so it is still valid after a restart
+type SpotifyUserClient struct {
+	*spotify.Client
+	tokens *storedTokenSource
+}
+
+// refreshes the token of user with source and stores every new token in the DB
+type storedTokenSource struct {
+	queries db.Store
+	user    string
+	source  oauth2.TokenSource
+
+	mutex sync.Mutex
+	token *oauth2.Token // the token which is stored in the DB
+}
+
+func (tokens *storedTokenSource) Token() (*oauth2.Token, error) {
+	tokens.mutex.Lock()
+	defer tokens.mutex.Unlock()
+
+	token, err := tokens.source.Token()
+	if err != nil {
+		return nil, err
+	}
+	if token.AccessToken == tokens.token.AccessToken {
+		return token, nil
+	}
+
+	// the request goes on with the new token, a failed write only
+	// costs another refresh after the next restart
+	if err := storeSpotifyToken(context.Background(), tokens.queries, tokens.user, token); err != nil {
+		slog.Warn("could not store refreshed spotify token", "user-id", tokens.user, "err", err)
+	} else {
+		slog.Debug("stored refreshed spotify token", "user-id", tokens.user)
+	}
+	tokens.token = token
+	return token, nil
+}
+
+// tok has to be stored in the DB already
+func (app *App) newSpotifyUserClient(user string, tok *oauth2.Token) *SpotifyUserClient {
+	tokens := &storedTokenSource{
+		queries: app.queries,
+		user:    user,
+		source:  app.spotifyOAuth.TokenSource(context.Background(), tok),
+		token:   tok,
+	}
+	return &SpotifyUserClient{
+		Client: app.newSpotifyClient(tokens),
+		tokens: tokens,
+	}
+}
+
+// returns the spotify client of the user
+// if the user was loaded from the DB the client is created from the stored token
+func (app *App) userSpotifyClient(ctx context.Context, user *ActiveUser) (*spotify.Client, error) {
+	user.spotifyMutex.Lock()
+	defer user.spotifyMutex.Unlock()
+
+	if user.spotify != nil {
+		return user.spotify.Client, nil
+	}
+
+	token, err := app.queries.GetSpotifyToken(ctx, user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load spotify token from DB: %w", err)
+	}
+
+	user.spotify = app.newSpotifyUserClient(user.ID, &oauth2.Token{
+		AccessToken:  token.AccessToken,
+		RefreshToken: token.RefreshToken,
+		TokenType:    token.TokenType,
+		Expiry:       token.Expiry,
+	})
+	return user.spotify.Client, nil
+}
+
+func storeSpotifyToken(ctx context.Context, queries db.Store, user string, tok *oauth2.Token) error {
+	if err := queries.SetSpotifyToken(ctx, db.SetSpotifyTokenParams{
+		User:         user,
+		AccessToken:  tok.AccessToken,
+		RefreshToken: tok.RefreshToken,
+		TokenType:    tok.TokenType,
+		Expiry:       tok.Expiry,
+	}); err != nil {
+		return fmt.Errorf("unable to store spotify token in db: %w", err)
+	}
+	return nil
+}
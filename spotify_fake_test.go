@@ -0,0 +1,381 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+const (
+	fake_spotify_client_id     = "ffs-test-client"
+	fake_spotify_client_secret = "ffs-test-secret"
+	// the user who is logged in at the accounts service in a browser
+	fake_spotify_login_cookie = "fake-spotify-user"
+)
+
+type fakeSpotifyTrack struct {
+	ID      string // empty for local files
+	Name    string
+	Artists []string
+	Image   string
+}
+
+type fakeSpotifyPlaylist struct {
+	ID     string
+	Name   string
+	Owner  string // if set, the playlist is private to its owner
+	Tracks []fakeSpotifyTrack
+}
+
+// an in-process fake of the spotify accounts service and the parts of the web api the app uses
+// the accounts service is served at URL and the web api at URL + "/v1"
+type fakeSpotify struct {
+	*httptest.Server
+	t *testing.T
+
+	mutex         sync.Mutex
+	loginAs       string // the user who authorizes the app, unless the browser has a login cookie
+	pageSize      int    // items per page of playlist items
+	tokenLifetime time.Duration
+	users         map[string]string // id -> display name
+	playlists     map[string]fakeSpotifyPlaylist
+	codes         map[string]fakeSpotifyCode
+	accessTokens  map[string]fakeSpotifyToken
+	refreshTokens map[string]fakeSpotifyCode // the code the refresh token was issued for
+	nextToken     int
+	requests      map[string]int // number of requests by route pattern
+}
+
+type fakeSpotifyCode struct {
+	user      string
+	challenge string // PKCE code challenge, empty if the app did not use PKCE
+}
+
+type fakeSpotifyToken struct {
+	user   string
+	expiry time.Time
+}
+
+func newFakeSpotify(t *testing.T) *fakeSpotify {
+	fake := &fakeSpotify{
+		t:             t,
+		pageSize:      100,
+		tokenLifetime: time.Hour,
+		users:         map[string]string{},
+		playlists:     map[string]fakeSpotifyPlaylist{},
+		codes:         map[string]fakeSpotifyCode{},
+		accessTokens:  map[string]fakeSpotifyToken{},
+		refreshTokens: map[string]fakeSpotifyCode{},
+		requests:      map[string]int{},
+	}
+
+	mux := http.NewServeMux()
+	fake.handle(mux, "GET /authorize", fake.authorizeHandler)
+	fake.handle(mux, "POST /api/token", fake.tokenHandler)
+	fake.handle(mux, "GET /v1/me", fake.authenticated(fake.meHandler))
+	fake.handle(mux, "GET /v1/playlists/{id}", fake.authenticated(fake.playlistHandler))
+	fake.handle(mux, "GET /v1/playlists/{id}/tracks", fake.authenticated(fake.playlistItemsHandler))
+
+	fake.Server = httptest.NewServer(mux)
+	t.Cleanup(fake.Close)
+	return fake
+}
+
+func (fake *fakeSpotify) handle(mux *http.ServeMux, pattern string, handler http.HandlerFunc) {
+	mux.HandleFunc(pattern, func(w http.ResponseWriter, r *http.Request) {
+		fake.mutex.Lock()
+		fake.requests[pattern]++
+		fake.mutex.Unlock()
+		handler(w, r)
+	})
+}
+
+// adds a user, the first one added logs in by default
+func (fake *fakeSpotify) AddUser(id, displayName string) {
+	fake.mutex.Lock()
+	defer fake.mutex.Unlock()
+	fake.users[id] = displayName
+	if fake.loginAs == "" {
+		fake.loginAs = id
+	}
+}
+
+func (fake *fakeSpotify) LoginAs(id string) {
+	fake.mutex.Lock()
+	defer fake.mutex.Unlock()
+	fake.loginAs = id
+}
+
+func (fake *fakeSpotify) AddPlaylist(playlist fakeSpotifyPlaylist) {
+	fake.mutex.Lock()
+	defer fake.mutex.Unlock()
+	fake.playlists[playlist.ID] = playlist
+}
+
+func (fake *fakeSpotify) SetPageSize(size int) {
+	fake.mutex.Lock()
+	defer fake.mutex.Unlock()
+	fake.pageSize = size
+}
+
+// tokens issued afterwards expire after lifetime
+func (fake *fakeSpotify) SetTokenLifetime(lifetime time.Duration) {
+	fake.mutex.Lock()
+	defer fake.mutex.Unlock()
+	fake.tokenLifetime = lifetime
+}
+
+// the number of requests to the route, e.g. "POST /api/token"
+func (fake *fakeSpotify) Requests(pattern string) int {
+	fake.mutex.Lock()
+	defer fake.mutex.Unlock()
+	return fake.requests[pattern]
+}
+
+// the user authorizes the app right away and is redirected back with a code
+func (fake *fakeSpotify) authorizeHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	if query.Get("client_id") != fake_spotify_client_id || query.Get("response_type") != "code" {
+		writeFakeSpotifyError(w, http.StatusBadRequest, "invalid authorize request")
+		return
+	}
+	if method := query.Get("code_challenge_method"); query.Has("code_challenge") && method != "S256" {
+		writeFakeSpotifyError(w, http.StatusBadRequest, "unsupported code_challenge_method "+method)
+		return
+	}
+	redirect, err := url.Parse(query.Get("redirect_uri"))
+	if err != nil || redirect.Host == "" {
+		writeFakeSpotifyError(w, http.StatusBadRequest, "invalid redirect_uri")
+		return
+	}
+
+	fake.mutex.Lock()
+	user := fake.loginAs
+	if cookie, err := r.Cookie(fake_spotify_login_cookie); err == nil {
+		user = cookie.Value
+	}
+	code := fake.newTokenLocked("code")
+	fake.codes[code] = fakeSpotifyCode{user: user, challenge: query.Get("code_challenge")}
+	fake.mutex.Unlock()
+
+	params := redirect.Query()
+	params.Set("code", code)
+	params.Set("state", query.Get("state"))
+	redirect.RawQuery = params.Encode()
+	http.Redirect(w, r, redirect.String(), http.StatusFound)
+}
+
+func (fake *fakeSpotify) tokenHandler(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		writeFakeSpotifyError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	// oauth2 sends the client credentials either as basic auth or in the form
+	clientID, clientSecret, ok := r.BasicAuth()
+	if !ok {
+		clientID, clientSecret = r.PostForm.Get("client_id"), r.PostForm.Get("client_secret")
+	}
+	// public clients have no secret, they have to use PKCE instead
+	public := clientSecret == ""
+	if clientID != fake_spotify_client_id || (!public && clientSecret != fake_spotify_client_secret) {
+		writeFakeSpotifyOAuthError(w, http.StatusUnauthorized, "invalid_client")
+		return
+	}
+
+	fake.mutex.Lock()
+	defer fake.mutex.Unlock()
+
+	var code fakeSpotifyCode
+	switch r.PostForm.Get("grant_type") {
+	case "authorization_code":
+		code, ok = fake.codes[r.PostForm.Get("code")]
+		delete(fake.codes, r.PostForm.Get("code"))
+		if ok && code.challenge != "" {
+			verifier := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+			ok = base64.RawURLEncoding.EncodeToString(verifier[:]) == code.challenge
+		}
+	case "refresh_token":
+		code, ok = fake.refreshTokens[r.PostForm.Get("refresh_token")]
+	default:
+		writeFakeSpotifyOAuthError(w, http.StatusBadRequest, "unsupported_grant_type")
+		return
+	}
+	if !ok || (public && code.challenge == "") {
+		writeFakeSpotifyOAuthError(w, http.StatusBadRequest, "invalid_grant")
+		return
+	}
+	user := code.user
+
+	accessToken := fake.newTokenLocked("access")
+	fake.accessTokens[accessToken] = fakeSpotifyToken{user: user, expiry: time.Now().Add(fake.tokenLifetime)}
+	response := map[string]any{
+		"access_token": accessToken,
+		"token_type":   "Bearer",
+		"expires_in":   int(fake.tokenLifetime.Seconds()),
+		"scope":        r.PostForm.Get("scope"),
+	}
+	// like spotify, a refresh keeps the refresh token
+	if r.PostForm.Get("grant_type") == "authorization_code" {
+		refreshToken := fake.newTokenLocked("refresh")
+		fake.refreshTokens[refreshToken] = code
+		response["refresh_token"] = refreshToken
+	}
+	writeFakeSpotifyJSON(w, http.StatusOK, response)
+}
+
+type fakeSpotifyHandler func(w http.ResponseWriter, r *http.Request, user string)
+
+// only lets requests with a valid access token through
+func (fake *fakeSpotify) authenticated(handler fakeSpotifyHandler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		accessToken, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+
+		fake.mutex.Lock()
+		token, exists := fake.accessTokens[accessToken]
+		fake.mutex.Unlock()
+
+		if !ok || !exists {
+			writeFakeSpotifyError(w, http.StatusUnauthorized, "Invalid access token")
+			return
+		}
+		if time.Now().After(token.expiry) {
+			writeFakeSpotifyError(w, http.StatusUnauthorized, "The access token expired")
+			return
+		}
+		handler(w, r, token.user)
+	}
+}
+
+func (fake *fakeSpotify) meHandler(w http.ResponseWriter, r *http.Request, user string) {
+	fake.mutex.Lock()
+	displayName := fake.users[user]
+	fake.mutex.Unlock()
+
+	writeFakeSpotifyJSON(w, http.StatusOK, map[string]any{
+		"id":           user,
+		"display_name": displayName,
+		"uri":          "spotify:user:" + user,
+		"type":         "user",
+	})
+}
+
+func (fake *fakeSpotify) playlistHandler(w http.ResponseWriter, r *http.Request, user string) {
+	playlist, ok := fake.playlistFor(r.PathValue("id"), user)
+	if !ok {
+		writeFakeSpotifyError(w, http.StatusNotFound, "Resource not found")
+		return
+	}
+
+	writeFakeSpotifyJSON(w, http.StatusOK, map[string]any{
+		"id":     playlist.ID,
+		"name":   playlist.Name,
+		"uri":    "spotify:playlist:" + playlist.ID,
+		"type":   "playlist",
+		"tracks": fake.playlistItemsPage(playlist, 0, 0),
+	})
+}
+
+func (fake *fakeSpotify) playlistItemsHandler(w http.ResponseWriter, r *http.Request, user string) {
+	playlist, ok := fake.playlistFor(r.PathValue("id"), user)
+	if !ok {
+		writeFakeSpotifyError(w, http.StatusNotFound, "Resource not found")
+		return
+	}
+
+	offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	writeFakeSpotifyJSON(w, http.StatusOK, fake.playlistItemsPage(playlist, offset, limit))
+}
+
+// like spotify, private playlists of other users are not found
+func (fake *fakeSpotify) playlistFor(id, user string) (fakeSpotifyPlaylist, bool) {
+	fake.mutex.Lock()
+	defer fake.mutex.Unlock()
+	playlist, ok := fake.playlists[id]
+	if playlist.Owner != "" && playlist.Owner != user {
+		return fakeSpotifyPlaylist{}, false
+	}
+	return playlist, ok
+}
+
+// a limit of 0 uses the page size of the fake
+func (fake *fakeSpotify) playlistItemsPage(playlist fakeSpotifyPlaylist, offset, limit int) map[string]any {
+	fake.mutex.Lock()
+	if limit <= 0 || limit > fake.pageSize {
+		limit = fake.pageSize
+	}
+	fake.mutex.Unlock()
+
+	offset = min(max(offset, 0), len(playlist.Tracks))
+	end := min(offset+limit, len(playlist.Tracks))
+
+	items := make([]map[string]any, 0, end-offset)
+	for _, track := range playlist.Tracks[offset:end] {
+		artists := make([]map[string]any, len(track.Artists))
+		for i, artist := range track.Artists {
+			artists[i] = map[string]any{"name": artist}
+		}
+		images := []map[string]any{}
+		if track.Image != "" {
+			images = append(images, map[string]any{"url": track.Image, "height": 640, "width": 640})
+		}
+		items = append(items, map[string]any{
+			"added_at": "2024-01-01T00:00:00Z",
+			"is_local": track.ID == "",
+			"track": map[string]any{
+				"type":     "track",
+				"id":       track.ID,
+				"name":     track.Name,
+				"artists":  artists,
+				"album":    map[string]any{"name": track.Name, "images": images},
+				"is_local": track.ID == "",
+			},
+		})
+	}
+
+	itemsURL := fmt.Sprintf("%s/v1/playlists/%s/tracks", fake.URL, playlist.ID)
+	var next any // null on the last page
+	if end < len(playlist.Tracks) {
+		next = fmt.Sprintf("%s?offset=%d&limit=%d", itemsURL, end, limit)
+	}
+	return map[string]any{
+		"href":   fmt.Sprintf("%s?offset=%d&limit=%d", itemsURL, offset, limit),
+		"items":  items,
+		"limit":  limit,
+		"offset": offset,
+		"total":  len(playlist.Tracks),
+		"next":   next,
+	}
+}
+
+func (fake *fakeSpotify) newTokenLocked(kind string) string {
+	fake.nextToken++
+	return fmt.Sprintf("%s-%d", kind, fake.nextToken)
+}
+
+func writeFakeSpotifyJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+// the error format of the web api
+func writeFakeSpotifyError(w http.ResponseWriter, status int, message string) {
+	writeFakeSpotifyJSON(w, status, map[string]any{
+		"error": map[string]any{"status": status, "message": message},
+	})
+}
+
+// the error format of the accounts service
+func writeFakeSpotifyOAuthError(w http.ResponseWriter, status int, oauthErr string) {
+	writeFakeSpotifyJSON(w, status, map[string]any{"error": oauthErr})
+}
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS user;
+DROP TABLE IF EXISTS match;
+DROP TABLE IF EXISTS session;
+DROP TABLE IF EXISTS playlist_item;
+DROP TABLE IF EXISTS playlist;
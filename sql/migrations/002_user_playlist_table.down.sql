@@ -0,0 +1 @@
+DROP TABLE IF EXISTS playlist_added_by_user;
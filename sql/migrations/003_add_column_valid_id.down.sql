@@ -0,0 +1,2 @@
+-- the empty ids which were replaced can't be restored
+ALTER TABLE playlist_item DROP COLUMN has_valid_spotify_id;
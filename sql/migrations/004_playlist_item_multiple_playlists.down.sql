@@ -0,0 +1,8 @@
+-- items of several playlists keep only one of them
+-- the column can't be NOT NULL, as it is added to existing rows
+ALTER TABLE playlist_item ADD COLUMN playlist varchar(22) REFERENCES playlist;
+
+UPDATE playlist_item
+SET playlist = (SELECT MIN(b.playlist) FROM playlist_item_belongs_to_playlist b WHERE b.playlist_item = playlist_item.id);
+
+DROP TABLE IF EXISTS playlist_item_belongs_to_playlist;
@@ -0,0 +1,4 @@
+DROP TRIGGER IF EXISTS won_trigger;
+DROP TRIGGER IF EXISTS insert_match_trigger;
+
+DROP TABLE IF EXISTS possible_next_items;
@@ -0,0 +1 @@
+-- nothing to do, the replaced spaces can't be told apart from underscores which were there before
@@ -0,0 +1 @@
+ALTER TABLE match DROP COLUMN creation_timestamp;
@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS spotify_token (
+	user varchar(22) NOT NULL PRIMARY KEY REFERENCES user,
+	access_token TEXT NOT NULL,
+	refresh_token TEXT NOT NULL,
+	token_type TEXT NOT NULL,
+	expiry DATETIME NOT NULL
+);
@@ -0,0 +1,16 @@
+-- the trigger uses the dropped columns
+DROP TRIGGER IF EXISTS insert_match_trigger;
+
+ALTER TABLE possible_next_items DROP COLUMN played_round;
+ALTER TABLE possible_next_items DROP COLUMN losses;
+ALTER TABLE possible_next_items DROP COLUMN wins;
+
+ALTER TABLE session DROP COLUMN rounds;
+ALTER TABLE session DROP COLUMN mode;
+
+-- the trigger of V005
+CREATE TRIGGER IF NOT EXISTS insert_match_trigger INSERT ON match
+BEGIN
+	UPDATE possible_next_items SET lost = TRUE WHERE session = new.session AND playlist_item = new.loser;
+	UPDATE possible_next_items SET won_round = new.round_number WHERE session = new.session AND playlist_item = new.winner;
+END;
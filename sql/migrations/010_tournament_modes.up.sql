@@ -0,0 +1,24 @@
+ALTER TABLE session ADD COLUMN mode varchar(32) NOT NULL DEFAULT 'knockout';
+ALTER TABLE session ADD COLUMN rounds INTEGER NOT NULL DEFAULT 0; -- number of rounds for modes with a fixed round count (swiss)
+
+ALTER TABLE possible_next_items ADD COLUMN wins INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE possible_next_items ADD COLUMN losses INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE possible_next_items ADD COLUMN played_round INTEGER NOT NULL DEFAULT -1; -- last round_number in which this item played
+
+UPDATE possible_next_items
+SET wins = (SELECT COUNT(*) FROM match m WHERE m.session = possible_next_items.session AND m.winner = possible_next_items.playlist_item),
+	losses = (SELECT COUNT(*) FROM match m WHERE m.session = possible_next_items.session AND m.loser = possible_next_items.playlist_item),
+	played_round = (
+		SELECT IFNULL(MAX(m.round_number), -1) FROM match m
+		WHERE m.session = possible_next_items.session
+		AND (m.winner = possible_next_items.playlist_item OR m.loser = possible_next_items.playlist_item)
+	);
+
+-- eliminating items is now up to the tournament mode of the session
+DROP TRIGGER IF EXISTS insert_match_trigger;
+
+CREATE TRIGGER IF NOT EXISTS insert_match_trigger INSERT ON match
+BEGIN
+	UPDATE possible_next_items SET losses = losses + 1, played_round = new.round_number WHERE session = new.session AND playlist_item = new.loser;
+	UPDATE possible_next_items SET wins = wins + 1, won_round = new.round_number, played_round = new.round_number WHERE session = new.session AND playlist_item = new.winner;
+END;
@@ -0,0 +1,6 @@
+CREATE TABLE IF NOT EXISTS ranking (
+	session INTEGER NOT NULL REFERENCES session,
+	playlist_item varchar(22) NOT NULL REFERENCES playlist_item,
+	position INTEGER NOT NULL, -- 1 is the favourite
+	PRIMARY KEY (session, playlist_item)
+);
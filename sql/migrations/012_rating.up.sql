@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS rating (
+	user varchar(22) NOT NULL REFERENCES user,
+	playlist_item varchar(22) NOT NULL REFERENCES playlist_item,
+	rating REAL NOT NULL, -- elo rating
+	matches INTEGER NOT NULL, -- number of matches the rating is based on
+	PRIMARY KEY (user, playlist_item)
+);
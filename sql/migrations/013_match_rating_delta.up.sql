@@ -0,0 +1,2 @@
+-- rating change of the match, NULL for matches played before ratings existed
+ALTER TABLE match ADD COLUMN rating_delta REAL;
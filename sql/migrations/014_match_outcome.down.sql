@@ -0,0 +1,12 @@
+-- the triggers use the dropped column
+DROP TRIGGER IF EXISTS insert_tie_match_trigger;
+DROP TRIGGER IF EXISTS insert_match_trigger;
+
+ALTER TABLE match DROP COLUMN outcome;
+
+-- the trigger of V010
+CREATE TRIGGER IF NOT EXISTS insert_match_trigger INSERT ON match
+BEGIN
+	UPDATE possible_next_items SET losses = losses + 1, played_round = new.round_number WHERE session = new.session AND playlist_item = new.loser;
+	UPDATE possible_next_items SET wins = wins + 1, won_round = new.round_number, played_round = new.round_number WHERE session = new.session AND playlist_item = new.winner;
+END;
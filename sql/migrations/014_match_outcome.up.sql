@@ -0,0 +1,15 @@
+ALTER TABLE match ADD COLUMN outcome varchar(8) NOT NULL DEFAULT 'win'; -- 'win', 'tie' (both advance) or 'skip' (no result)
+
+-- ties let both items advance, skips don't change anything
+DROP TRIGGER IF EXISTS insert_match_trigger;
+
+CREATE TRIGGER IF NOT EXISTS insert_match_trigger INSERT ON match WHEN new.outcome = 'win'
+BEGIN
+	UPDATE possible_next_items SET losses = losses + 1, played_round = new.round_number WHERE session = new.session AND playlist_item = new.loser;
+	UPDATE possible_next_items SET wins = wins + 1, won_round = new.round_number, played_round = new.round_number WHERE session = new.session AND playlist_item = new.winner;
+END;
+
+CREATE TRIGGER IF NOT EXISTS insert_tie_match_trigger INSERT ON match WHEN new.outcome = 'tie'
+BEGIN
+	UPDATE possible_next_items SET won_round = new.round_number, played_round = new.round_number WHERE session = new.session AND playlist_item IN (new.winner, new.loser);
+END;
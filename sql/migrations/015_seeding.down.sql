@@ -0,0 +1 @@
+ALTER TABLE possible_next_items DROP COLUMN seed;
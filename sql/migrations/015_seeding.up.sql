@@ -0,0 +1 @@
+ALTER TABLE possible_next_items ADD COLUMN seed INTEGER; -- 1 is the strongest item, NULL if the session is not seeded
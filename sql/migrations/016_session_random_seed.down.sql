@@ -0,0 +1 @@
+ALTER TABLE session DROP COLUMN random_seed;
@@ -0,0 +1,2 @@
+ALTER TABLE session ADD COLUMN random_seed INTEGER NOT NULL DEFAULT 0; -- seed for the pairing order of the session
+UPDATE session SET random_seed = RANDOM();
@@ -0,0 +1,5 @@
+DROP TRIGGER IF EXISTS delete_group_session_trigger;
+
+DROP TABLE IF EXISTS group_vote;
+DROP TABLE IF EXISTS group_member;
+DROP TABLE IF EXISTS group_session;
@@ -0,0 +1,33 @@
+-- a session which several users decide together
+-- session.user is the user who created it
+CREATE TABLE IF NOT EXISTS group_session (
+	session INTEGER NOT NULL PRIMARY KEY REFERENCES session,
+	invite_code varchar(16) NOT NULL UNIQUE,
+	vote_timeout INTEGER NOT NULL, -- in seconds
+	pair_first varchar(22) REFERENCES playlist_item, -- the pair which is currently voted on
+	pair_second varchar(22) REFERENCES playlist_item,
+	pair_started DATETIME
+);
+
+CREATE TABLE IF NOT EXISTS group_member (
+	session INTEGER NOT NULL REFERENCES session,
+	user varchar(22) NOT NULL REFERENCES user,
+	PRIMARY KEY (session, user)
+);
+
+-- votes on the current pair of a group session
+CREATE TABLE IF NOT EXISTS group_vote (
+	session INTEGER NOT NULL REFERENCES session,
+	user varchar(22) NOT NULL REFERENCES user,
+	winner varchar(22) NOT NULL REFERENCES playlist_item,
+	loser varchar(22) NOT NULL REFERENCES playlist_item,
+	outcome varchar(8) NOT NULL, -- same as match.outcome
+	PRIMARY KEY (session, user)
+);
+
+CREATE TRIGGER IF NOT EXISTS delete_group_session_trigger DELETE ON session
+BEGIN
+	DELETE FROM group_vote WHERE session = old.id;
+	DELETE FROM group_member WHERE session = old.id;
+	DELETE FROM group_session WHERE session = old.id;
+END;
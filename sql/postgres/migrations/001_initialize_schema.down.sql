@@ -0,0 +1,20 @@
+DROP TRIGGER IF EXISTS won_trigger ON session;
+DROP FUNCTION IF EXISTS session_won;
+DROP TRIGGER IF EXISTS insert_match_trigger ON match;
+DROP FUNCTION IF EXISTS insert_match;
+
+DROP TABLE IF EXISTS group_vote;
+DROP TABLE IF EXISTS group_member;
+DROP TABLE IF EXISTS group_session;
+DROP TABLE IF EXISTS rating;
+DROP TABLE IF EXISTS ranking;
+DROP TABLE IF EXISTS spotify_token;
+DROP TABLE IF EXISTS possible_next_items;
+DROP TABLE IF EXISTS playlist_added_by_user;
+DROP TABLE IF EXISTS match;
+ALTER TABLE IF EXISTS "user" DROP CONSTRAINT IF EXISTS user_current_session_fkey;
+DROP TABLE IF EXISTS session;
+DROP TABLE IF EXISTS "user";
+DROP TABLE IF EXISTS playlist_item_belongs_to_playlist;
+DROP TABLE IF EXISTS playlist_item;
+DROP TABLE IF EXISTS playlist;
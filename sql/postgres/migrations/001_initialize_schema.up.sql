@@ -0,0 +1,151 @@
+-- the schema of the sqlite migrations up to 017_group_session
+-- the postgres migrations are numbered on their own, a new migration has to be added to both
+-- user is a keyword in postgres and has to be quoted
+-- integers are BIGINT and booleans 0 or 1, so the generated go types match the ones of sqlite
+
+CREATE TABLE IF NOT EXISTS playlist (
+	id varchar(22) NOT NULL PRIMARY KEY, -- spotify id
+	name varchar(128),
+	url varchar(128)
+);
+
+CREATE TABLE IF NOT EXISTS playlist_item (
+	id varchar(22) NOT NULL PRIMARY KEY, -- spotify id
+	title varchar(64),
+	artists varchar(64), -- comma separated list of artists
+	image varchar(64), -- URL to the image
+	has_valid_spotify_id BIGINT NOT NULL DEFAULT 1
+);
+
+CREATE TABLE IF NOT EXISTS playlist_item_belongs_to_playlist (
+	playlist_item varchar(22) NOT NULL REFERENCES playlist_item, -- spotify id
+	playlist varchar(22) NOT NULL REFERENCES playlist, -- spotify id
+	PRIMARY KEY (playlist_item, playlist)
+);
+
+-- current_session references session, which is created below
+CREATE TABLE IF NOT EXISTS "user" (
+	id varchar(22) NOT NULL PRIMARY KEY, -- spotify id
+	current_session BIGINT
+);
+
+CREATE TABLE IF NOT EXISTS session (
+	id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+	playlist varchar(22) NOT NULL REFERENCES playlist,
+	current_round BIGINT NOT NULL,
+	"user" varchar(22) NOT NULL REFERENCES "user",
+	winner varchar(22) REFERENCES playlist_item,
+	creation_timestamp TIMESTAMPTZ,
+	mode varchar(32) NOT NULL DEFAULT 'knockout',
+	rounds BIGINT NOT NULL DEFAULT 0, -- number of rounds for modes with a fixed round count (swiss)
+	random_seed BIGINT NOT NULL DEFAULT 0 -- seed for the pairing order of the session
+);
+
+-- sqlite doesn't enforce foreign keys, so deleting a session must not fail because of them
+ALTER TABLE "user" ADD FOREIGN KEY (current_session) REFERENCES session ON DELETE SET NULL;
+
+CREATE TABLE IF NOT EXISTS match (
+	id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+	session BIGINT NOT NULL,
+	round_number BIGINT NOT NULL,
+	winner varchar(22) NOT NULL REFERENCES playlist_item,
+	loser varchar(22) NOT NULL REFERENCES playlist_item,
+	creation_timestamp TIMESTAMPTZ,
+	rating_delta DOUBLE PRECISION, -- rating change of the match, NULL for matches played before ratings existed
+	outcome varchar(8) NOT NULL DEFAULT 'win' -- 'win', 'tie' (both advance) or 'skip' (no result)
+);
+
+CREATE TABLE IF NOT EXISTS playlist_added_by_user (
+	"user" varchar(22) NOT NULL REFERENCES "user",
+	playlist varchar(22) NOT NULL REFERENCES playlist,
+	PRIMARY KEY ("user", playlist)
+);
+
+CREATE TABLE IF NOT EXISTS possible_next_items (
+	session BIGINT NOT NULL REFERENCES session ON DELETE CASCADE,
+	playlist_item varchar(22) NOT NULL,
+	lost BIGINT NOT NULL, -- BOOLEAN wether this item already is a loser
+	won_round BIGINT NOT NULL, -- last round_number in which this item won
+	wins BIGINT NOT NULL DEFAULT 0,
+	losses BIGINT NOT NULL DEFAULT 0,
+	played_round BIGINT NOT NULL DEFAULT -1, -- last round_number in which this item played
+	seed BIGINT, -- 1 is the strongest item, NULL if the session is not seeded
+	PRIMARY KEY (session, playlist_item)
+);
+
+CREATE TABLE IF NOT EXISTS spotify_token (
+	"user" varchar(22) NOT NULL PRIMARY KEY REFERENCES "user",
+	access_token TEXT NOT NULL,
+	refresh_token TEXT NOT NULL,
+	token_type TEXT NOT NULL,
+	expiry TIMESTAMPTZ NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS ranking (
+	session BIGINT NOT NULL REFERENCES session ON DELETE CASCADE,
+	playlist_item varchar(22) NOT NULL REFERENCES playlist_item,
+	position BIGINT NOT NULL, -- 1 is the favourite
+	PRIMARY KEY (session, playlist_item)
+);
+
+CREATE TABLE IF NOT EXISTS rating (
+	"user" varchar(22) NOT NULL REFERENCES "user",
+	playlist_item varchar(22) NOT NULL REFERENCES playlist_item,
+	rating DOUBLE PRECISION NOT NULL, -- elo rating
+	matches BIGINT NOT NULL, -- number of matches the rating is based on
+	PRIMARY KEY ("user", playlist_item)
+);
+
+-- a session which several users decide together
+-- session.user is the user who created it
+-- the cascades replace the delete_group_session_trigger of sqlite
+CREATE TABLE IF NOT EXISTS group_session (
+	session BIGINT NOT NULL PRIMARY KEY REFERENCES session ON DELETE CASCADE,
+	invite_code varchar(16) NOT NULL UNIQUE,
+	vote_timeout BIGINT NOT NULL, -- in seconds
+	pair_first varchar(22) REFERENCES playlist_item, -- the pair which is currently voted on
+	pair_second varchar(22) REFERENCES playlist_item,
+	pair_started TIMESTAMPTZ
+);
+
+CREATE TABLE IF NOT EXISTS group_member (
+	session BIGINT NOT NULL REFERENCES session ON DELETE CASCADE,
+	"user" varchar(22) NOT NULL REFERENCES "user",
+	PRIMARY KEY (session, "user")
+);
+
+-- votes on the current pair of a group session
+CREATE TABLE IF NOT EXISTS group_vote (
+	session BIGINT NOT NULL REFERENCES session ON DELETE CASCADE,
+	"user" varchar(22) NOT NULL REFERENCES "user",
+	winner varchar(22) NOT NULL REFERENCES playlist_item,
+	loser varchar(22) NOT NULL REFERENCES playlist_item,
+	outcome varchar(8) NOT NULL, -- same as match.outcome
+	PRIMARY KEY (session, "user")
+);
+
+-- ties let both items advance, skips don't change anything
+CREATE OR REPLACE FUNCTION insert_match() RETURNS TRIGGER AS $$
+BEGIN
+	IF new.outcome = 'win' THEN
+		UPDATE possible_next_items SET losses = losses + 1, played_round = new.round_number WHERE session = new.session AND playlist_item = new.loser;
+		UPDATE possible_next_items SET wins = wins + 1, won_round = new.round_number, played_round = new.round_number WHERE session = new.session AND playlist_item = new.winner;
+	ELSIF new.outcome = 'tie' THEN
+		UPDATE possible_next_items SET won_round = new.round_number, played_round = new.round_number WHERE session = new.session AND playlist_item IN (new.winner, new.loser);
+	END IF;
+	RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER insert_match_trigger AFTER INSERT ON match
+FOR EACH ROW EXECUTE FUNCTION insert_match();
+
+CREATE OR REPLACE FUNCTION session_won() RETURNS TRIGGER AS $$
+BEGIN
+	DELETE FROM possible_next_items WHERE session = new.id;
+	RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER won_trigger AFTER UPDATE OF winner ON session
+FOR EACH ROW EXECUTE FUNCTION session_won();
@@ -0,0 +1,45 @@
+-- name: AddGroupSession :exec
+INSERT INTO group_session
+(session, invite_code, vote_timeout, pair_first, pair_second, pair_started) VALUES ($1, $2, $3, NULL, NULL, NULL);
+
+-- name: GetGroupSession :one
+SELECT * FROM group_session
+WHERE session = $1;
+
+-- name: GetGroupSessionByInviteCode :one
+SELECT * FROM group_session
+WHERE invite_code = $1;
+
+-- name: SetGroupPair :exec
+UPDATE group_session
+SET pair_first = $1, pair_second = $2, pair_started = CURRENT_TIMESTAMP
+WHERE session = $3;
+
+-- name: AddGroupMember :exec
+INSERT INTO group_member
+(session, "user") VALUES ($1, $2)
+ON CONFLICT DO NOTHING;
+
+-- name: GetActiveGroupMembers :many
+SELECT gm."user" FROM group_member gm
+INNER JOIN "user" u ON gm."user" = u.id
+WHERE gm.session = $1 AND u.current_session = gm.session;
+
+-- name: AddOrUpdateGroupVote :exec
+INSERT INTO group_vote
+(session, "user", winner, loser, outcome) VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (session, "user") DO UPDATE
+SET winner = EXCLUDED.winner, loser = EXCLUDED.loser, outcome = EXCLUDED.outcome;
+
+-- name: GetGroupVotes :many
+SELECT * FROM group_vote
+WHERE session = $1;
+
+-- name: DeleteGroupVotes :exec
+DELETE FROM group_vote WHERE session = $1;
+
+-- name: ResetCurrentSessionForGroupMembers :exec
+UPDATE "user" SET current_session = NULL
+WHERE current_session = sqlc.arg(session) AND id IN (
+	SELECT gm."user" FROM group_member gm WHERE gm.session = sqlc.arg(session)
+);
@@ -0,0 +1,35 @@
+-- name: GetPlaylist :one
+SELECT * FROM playlist
+WHERE id = $1 LIMIT 1;
+
+-- name: AddOrUpdatePlaylist :exec
+INSERT INTO playlist
+(id, name, url) VALUES ($1, $2, $3)
+ON CONFLICT (id) DO UPDATE
+SET name = EXCLUDED.name, url = EXCLUDED.url;
+
+-- name: GetPlaylistItem :one
+SELECT * FROM playlist_item
+WHERE id = $1;
+
+-- name: AddOrUpdatePlaylistItem :exec
+INSERT INTO playlist_item
+(id, title, artists, image, has_valid_spotify_id) VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (id) DO UPDATE
+SET title = EXCLUDED.title, artists = EXCLUDED.artists, image = EXCLUDED.image, has_valid_spotify_id = EXCLUDED.has_valid_spotify_id;
+
+-- name: AddPlaylistItemIfAbsent :exec
+INSERT INTO playlist_item
+(id, title, artists, image, has_valid_spotify_id) VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (id) DO NOTHING;
+
+-- name: AddPlaylistItemBelongsToPlaylist :exec
+INSERT INTO playlist_item_belongs_to_playlist
+(playlist_item, playlist) VALUES ($1, $2)
+ON CONFLICT DO NOTHING;
+
+-- name: DeleteItemFromPlaylist :exec
+DELETE FROM playlist_item_belongs_to_playlist WHERE playlist = $1 AND playlist_item = $2;
+
+-- name: GetItemIdsForPlaylist :many
+SELECT playlist_item FROM playlist_item_belongs_to_playlist WHERE playlist = $1;
@@ -0,0 +1,58 @@
+-- name: InitializePossibleNextItemsForSession :exec
+INSERT INTO possible_next_items (session, playlist_item, lost, won_round)
+SELECT sqlc.arg(session)::BIGINT, item.id, 0, -1
+FROM playlist_item item
+INNER JOIN playlist_item_belongs_to_playlist belongs ON item.id = belongs.playlist_item
+WHERE belongs.playlist = sqlc.arg(playlist);
+
+-- name: DeletePossibleNextItemsForSession :exec
+DELETE FROM possible_next_items WHERE session = $1;
+
+-- name: GetRemainingItems :many
+SELECT item.*, pn.wins, pn.losses, pn.played_round, pn.seed
+FROM possible_next_items pn
+INNER JOIN playlist_item item ON pn.playlist_item = item.id
+WHERE pn.session = $1 AND pn.lost = 0
+ORDER BY item.id COLLATE "C";
+
+-- name: EliminateItemsWithLosses :exec
+UPDATE possible_next_items SET lost = 1
+WHERE session = $1 AND losses >= $2;
+
+-- name: ResetPossibleNextItemsForSession :exec
+UPDATE possible_next_items
+SET lost = 0,
+	wins = (
+		SELECT COUNT(*) FROM match m
+		WHERE m.session = possible_next_items.session AND m.outcome = 'win' AND m.winner = possible_next_items.playlist_item
+	),
+	losses = (
+		SELECT COUNT(*) FROM match m
+		WHERE m.session = possible_next_items.session AND m.outcome = 'win' AND m.loser = possible_next_items.playlist_item
+	),
+	won_round = (
+		SELECT COALESCE(MAX(m.round_number), -1) FROM match m
+		WHERE m.session = possible_next_items.session
+		AND ((m.outcome = 'win' AND m.winner = possible_next_items.playlist_item)
+			OR (m.outcome = 'tie' AND (m.winner = possible_next_items.playlist_item OR m.loser = possible_next_items.playlist_item)))
+	),
+	played_round = (
+		SELECT COALESCE(MAX(m.round_number), -1) FROM match m
+		WHERE m.session = possible_next_items.session AND m.outcome != 'skip'
+		AND (m.winner = possible_next_items.playlist_item OR m.loser = possible_next_items.playlist_item)
+	)
+WHERE session = $1;
+
+-- name: SetSeed :exec
+UPDATE possible_next_items SET seed = $1
+WHERE session = $2 AND playlist_item = $3;
+
+-- name: CountPossibleNextItemsForSession :one
+SELECT COUNT(*) FROM possible_next_items
+WHERE session = $1;
+
+-- name: ReviveItemsWithFewestLosses :exec
+UPDATE possible_next_items SET lost = 0
+WHERE session = sqlc.arg(session) AND losses = (
+	SELECT MIN(pn.losses) FROM possible_next_items pn WHERE pn.session = sqlc.arg(session)
+);
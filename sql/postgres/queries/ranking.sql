@@ -0,0 +1,12 @@
+-- name: AddOrUpdateRankingItem :exec
+INSERT INTO ranking
+(session, playlist_item, position) VALUES ($1, $2, $3)
+ON CONFLICT (session, playlist_item) DO UPDATE
+SET position = EXCLUDED.position;
+
+-- name: GetRankingForSession :many
+SELECT r.position, item.*
+FROM ranking r
+INNER JOIN playlist_item item ON r.playlist_item = item.id
+WHERE r.session = $1
+ORDER BY r.position ASC;
@@ -0,0 +1,21 @@
+-- name: GetRating :one
+SELECT * FROM rating
+WHERE "user" = $1 AND playlist_item = $2;
+
+-- name: AddOrUpdateRating :exec
+INSERT INTO rating
+("user", playlist_item, rating, matches) VALUES ($1, $2, $3, $4)
+ON CONFLICT ("user", playlist_item) DO UPDATE
+SET rating = EXCLUDED.rating, matches = EXCLUDED.matches;
+
+-- name: GetRatingLeaderboard :many
+SELECT pi.id, pi.title, pi.artists, pi.image,
+	COALESCE(r.rating, sqlc.arg(default_rating)::DOUBLE PRECISION)::DOUBLE PRECISION AS rating,
+	COALESCE(r.matches, 0)::BIGINT AS matches
+FROM playlist_item_belongs_to_playlist pibtp
+INNER JOIN playlist_item pi
+ON pi.id = pibtp.playlist_item
+LEFT JOIN rating r
+ON r.playlist_item = pi.id AND r."user" = sqlc.arg('user')
+WHERE pibtp.playlist = sqlc.arg(playlist)
+ORDER BY rating DESC;
@@ -0,0 +1,65 @@
+-- name: AddSession :one
+INSERT INTO session
+(playlist, current_round, "user", winner, creation_timestamp, mode, rounds, random_seed) VALUES ($1, 0, $2, NULL, CURRENT_TIMESTAMP, $3, $4, $5)
+RETURNING session.id;
+
+-- name: GetWinner :one
+SELECT winner FROM session
+WHERE id = $1;
+
+-- name: SetWinner :exec
+UPDATE session
+SET winner = $1
+WHERE id = $2;
+
+-- name: GetCurrentRound :one
+SELECT current_round FROM session
+WHERE id = $1;
+
+-- name: SetCurrentRound :exec
+UPDATE session
+SET current_round = $1
+WHERE id = $2;
+
+-- name: AddMatch :exec
+INSERT INTO match
+(session, round_number, winner, loser, creation_timestamp, rating_delta, outcome) VALUES ($1, $2, $3, $4, CURRENT_TIMESTAMP, $5, $6);
+
+-- name: CountMatchesForRound :one
+SELECT COUNT(*) FROM match
+WHERE session = $1 AND round_number = $2 AND outcome != 'skip';
+
+-- name: GetSession :one
+SELECT * FROM session
+WHERE id = $1;
+
+-- name: GetNumberOfMatchesCompleted :one
+SELECT COUNT(*) FROM match
+WHERE session = $1 AND outcome != 'skip';
+
+-- name: DeleteSession :exec
+DELETE FROM session WHERE id = $1;
+
+-- name: DeleteMatchesForSession :exec
+DELETE FROM match WHERE session = $1;
+
+-- name: GetMatchesForSession :many
+SELECT * FROM match
+WHERE session = $1;
+
+-- name: GetLatestMatchForSession :one
+SELECT * FROM match
+WHERE session = $1
+ORDER BY id DESC LIMIT 1;
+
+-- name: DeleteMatch :exec
+DELETE FROM match WHERE id = $1;
+
+-- name: GetAllSessions :many
+SELECT * FROM session
+ORDER BY id;
+
+-- name: GetSessionsForUser :many
+SELECT * FROM session
+WHERE "user" = $1
+ORDER BY id;
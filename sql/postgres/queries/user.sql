@@ -0,0 +1,40 @@
+-- name: AddUser :one
+INSERT INTO "user" (id, current_session) VALUES ($1, NULL)
+ON CONFLICT DO NOTHING
+RETURNING *;
+
+-- name: GetUser :one
+SELECT * FROM "user"
+WHERE id = $1 LIMIT 1;
+
+-- name: SetUserSession :exec
+UPDATE "user"
+SET current_session = $1
+WHERE id = $2;
+
+-- name: GetAllWinnersForUser :many
+SELECT winner FROM session
+WHERE "user" = $1 AND winner IS NOT NULL;
+
+-- name: AddPlaylistAddedByUser :exec
+INSERT INTO playlist_added_by_user
+("user", playlist) VALUES ($1, $2)
+ON CONFLICT DO NOTHING;
+
+-- name: GetPlaylistsForUser :many
+SELECT p.* FROM playlist_added_by_user pa, playlist p
+WHERE pa."user" = $1 AND p.id = pa.playlist;
+
+-- name: GetNonActiveUserSessions :many
+SELECT * FROM session
+WHERE "user" = $1 AND id != sqlc.arg(activeSession) AND winner IS NULL;
+
+-- name: GetSpotifyToken :one
+SELECT * FROM spotify_token
+WHERE "user" = $1;
+
+-- name: SetSpotifyToken :exec
+INSERT INTO spotify_token
+("user", access_token, refresh_token, token_type, expiry) VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT ("user") DO UPDATE
+SET access_token = EXCLUDED.access_token, refresh_token = EXCLUDED.refresh_token, token_type = EXCLUDED.token_type, expiry = EXCLUDED.expiry;
@@ -0,0 +1,42 @@
+-- name: AddGroupSession :exec
+INSERT INTO group_session
+(session, invite_code, vote_timeout, pair_first, pair_second, pair_started) VALUES (?, ?, ?, NULL, NULL, NULL);
+
+-- name: GetGroupSession :one
+SELECT * FROM group_session
+WHERE session = ?;
+
+-- name: GetGroupSessionByInviteCode :one
+SELECT * FROM group_session
+WHERE invite_code = ?;
+
+-- name: SetGroupPair :exec
+UPDATE group_session
+SET pair_first = ?, pair_second = ?, pair_started = CURRENT_TIMESTAMP
+WHERE session = ?;
+
+-- name: AddGroupMember :exec
+INSERT OR IGNORE INTO group_member
+(session, user) VALUES (?, ?);
+
+-- name: GetActiveGroupMembers :many
+SELECT gm.user FROM group_member gm
+INNER JOIN user u ON gm.user = u.id
+WHERE gm.session = ? AND u.current_session = gm.session;
+
+-- name: AddOrUpdateGroupVote :exec
+INSERT OR REPLACE INTO group_vote
+(session, user, winner, loser, outcome) VALUES (?, ?, ?, ?, ?);
+
+-- name: GetGroupVotes :many
+SELECT * FROM group_vote
+WHERE session = ?;
+
+-- name: DeleteGroupVotes :exec
+DELETE FROM group_vote WHERE session = ?;
+
+-- name: ResetCurrentSessionForGroupMembers :exec
+UPDATE user SET current_session = NULL
+WHERE current_session = sqlc.arg(session) AND id IN (
+	SELECT gm.user FROM group_member gm WHERE gm.session = sqlc.arg(session)
+);
@@ -14,6 +14,10 @@ WHERE id = ?;
 INSERT OR REPLACE INTO playlist_item
 (id, title, artists, image, has_valid_spotify_id) VALUES (?, ?, ?, ?, ?);
 
+-- name: AddPlaylistItemIfAbsent :exec
+INSERT OR IGNORE INTO playlist_item
+(id, title, artists, image, has_valid_spotify_id) VALUES (?, ?, ?, ?, ?);
+
 -- name: AddPlaylistItemBelongsToPlaylist :exec
 INSERT OR IGNORE INTO playlist_item_belongs_to_playlist
 (playlist_item, playlist) VALUES (?, ?);
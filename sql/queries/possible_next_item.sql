@@ -5,12 +5,54 @@ FROM playlist_item item
 INNER JOIN playlist_item_belongs_to_playlist belongs ON item.id = belongs.playlist_item
 WHERE belongs.playlist = ?;
 
--- name: GetNextPair :many
-SELECT item.* 
-FROM possible_next_items pn 
-INNER JOIN playlist_item item ON pn.playlist_item = item.id
-WHERE pn.session = ? AND pn.lost = FALSE AND pn.won_round != sqlc.arg(current_round)
-ORDER BY RANDOM() DESC LIMIT 2;
-
 -- name: DeletePossibleNextItemsForSession :exec
 DELETE FROM possible_next_items WHERE session = ?;
+
+-- name: GetRemainingItems :many
+SELECT item.*, pn.wins, pn.losses, pn.played_round, pn.seed
+FROM possible_next_items pn
+INNER JOIN playlist_item item ON pn.playlist_item = item.id
+WHERE pn.session = ? AND pn.lost = FALSE
+ORDER BY item.id;
+
+-- name: EliminateItemsWithLosses :exec
+UPDATE possible_next_items SET lost = TRUE
+WHERE session = ? AND losses >= ?;
+
+-- name: ResetPossibleNextItemsForSession :exec
+UPDATE possible_next_items
+SET lost = FALSE,
+	wins = (
+		SELECT COUNT(*) FROM match m
+		WHERE m.session = possible_next_items.session AND m.outcome = 'win' AND m.winner = possible_next_items.playlist_item
+	),
+	losses = (
+		SELECT COUNT(*) FROM match m
+		WHERE m.session = possible_next_items.session AND m.outcome = 'win' AND m.loser = possible_next_items.playlist_item
+	),
+	won_round = (
+		SELECT IFNULL(MAX(m.round_number), -1) FROM match m
+		WHERE m.session = possible_next_items.session
+		AND ((m.outcome = 'win' AND m.winner = possible_next_items.playlist_item)
+			OR (m.outcome = 'tie' AND (m.winner = possible_next_items.playlist_item OR m.loser = possible_next_items.playlist_item)))
+	),
+	played_round = (
+		SELECT IFNULL(MAX(m.round_number), -1) FROM match m
+		WHERE m.session = possible_next_items.session AND m.outcome != 'skip'
+		AND (m.winner = possible_next_items.playlist_item OR m.loser = possible_next_items.playlist_item)
+	)
+WHERE session = ?;
+
+-- name: SetSeed :exec
+UPDATE possible_next_items SET seed = ?
+WHERE session = ? AND playlist_item = ?;
+
+-- name: CountPossibleNextItemsForSession :one
+SELECT COUNT(*) FROM possible_next_items
+WHERE session = ?;
+
+-- name: ReviveItemsWithFewestLosses :exec
+UPDATE possible_next_items SET lost = FALSE
+WHERE session = sqlc.arg(session) AND losses = (
+	SELECT MIN(pn.losses) FROM possible_next_items pn WHERE pn.session = sqlc.arg(session)
+);
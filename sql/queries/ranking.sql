@@ -0,0 +1,10 @@
+-- name: AddOrUpdateRankingItem :exec
+INSERT OR REPLACE INTO ranking
+(session, playlist_item, position) VALUES (?, ?, ?);
+
+-- name: GetRankingForSession :many
+SELECT r.position, item.*
+FROM ranking r
+INNER JOIN playlist_item item ON r.playlist_item = item.id
+WHERE r.session = ?
+ORDER BY r.position ASC;
@@ -0,0 +1,19 @@
+-- name: GetRating :one
+SELECT * FROM rating
+WHERE user = ? AND playlist_item = ?;
+
+-- name: AddOrUpdateRating :exec
+INSERT OR REPLACE INTO rating
+(user, playlist_item, rating, matches) VALUES (?, ?, ?, ?);
+
+-- name: GetRatingLeaderboard :many
+SELECT pi.id, pi.title, pi.artists, pi.image,
+	CAST(IFNULL(r.rating, sqlc.arg(default_rating)) AS REAL) AS rating,
+	CAST(IFNULL(r.matches, 0) AS INTEGER) AS matches
+FROM playlist_item_belongs_to_playlist pibtp
+INNER JOIN playlist_item pi
+ON pi.id = pibtp.playlist_item
+LEFT JOIN rating r
+ON r.playlist_item = pi.id AND r.user = sqlc.arg(user)
+WHERE pibtp.playlist = sqlc.arg(playlist)
+ORDER BY rating DESC;
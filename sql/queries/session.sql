@@ -1,6 +1,6 @@
 -- name: AddSession :one
 INSERT INTO session
-(id, playlist, current_round, user, winner, creation_timestamp) VALUES (NULL, ?, 0, ?, NULL, CURRENT_TIMESTAMP)
+(id, playlist, current_round, user, winner, creation_timestamp, mode, rounds, random_seed) VALUES (NULL, ?, 0, ?, NULL, CURRENT_TIMESTAMP, ?, ?, ?)
 RETURNING session.id;
 
 -- name: GetWinner :one
@@ -23,11 +23,11 @@ WHERE id = ?;
 
 -- name: AddMatch :exec
 INSERT INTO match
-(id, session, round_number, winner, loser, creation_timestamp) VALUES (NULL, ?, ?, ?, ?, CURRENT_TIMESTAMP);
+(id, session, round_number, winner, loser, creation_timestamp, rating_delta, outcome) VALUES (NULL, ?, ?, ?, ?, CURRENT_TIMESTAMP, ?, ?);
 
 -- name: CountMatchesForRound :one
 SELECT COUNT(*) FROM match
-WHERE session = ? AND round_number = ?;
+WHERE session = ? AND round_number = ? AND outcome != 'skip';
 
 -- name: GetSession :one
 SELECT * FROM session
@@ -35,10 +35,31 @@ WHERE id = ?;
 
 -- name: GetNumberOfMatchesCompleted :one
 SELECT COUNT(*) FROM match
-WHERE session = ?;
+WHERE session = ? AND outcome != 'skip';
 
 -- name: DeleteSession :exec
 DELETE FROM session WHERE id = ?;
 
 -- name: DeleteMatchesForSession :exec
 DELETE FROM match WHERE session = ?;
+
+-- name: GetMatchesForSession :many
+SELECT * FROM match
+WHERE session = ?;
+
+-- name: GetLatestMatchForSession :one
+SELECT * FROM match
+WHERE session = ?
+ORDER BY id DESC LIMIT 1;
+
+-- name: DeleteMatch :exec
+DELETE FROM match WHERE id = ?;
+
+-- name: GetAllSessions :many
+SELECT * FROM session
+ORDER BY id;
+
+-- name: GetSessionsForUser :many
+SELECT * FROM session
+WHERE user = ?
+ORDER BY id;
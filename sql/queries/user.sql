@@ -26,3 +26,11 @@ WHERE pa.user = ? AND p.id = pa.playlist;
 -- name: GetNonActiveUserSessions :many
 SELECT * FROM session
 WHERE user = ? AND id != sqlc.arg(activeSession) AND winner IS NULL;
+
+-- name: GetSpotifyToken :one
+SELECT * FROM spotify_token
+WHERE user = ?;
+
+-- name: SetSpotifyToken :exec
+INSERT OR REPLACE INTO spotify_token
+(user, access_token, refresh_token, token_type, expiry) VALUES (?, ?, ?, ?, ?);
@@ -0,0 +1,93 @@
+//go:build !sqlite_purego
+
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/golang-migrate/migrate/v4/database"
+	"github.com/golang-migrate/migrate/v4/database/sqlite3"
+	sqlite3_driver "github.com/mattn/go-sqlite3"
+)
+
+// sqlite through mattn/go-sqlite3, which needs cgo
+// build with -tags sqlite_purego for a build without cgo
+
+const (
+	sqlite_driver_name         = "sqlite3"
+	default_sqlite_data_source = "file:ffs.db?_journal_mode=WAL"
+)
+
+func sqlite_migration_driver(db *sql.DB) (database.Driver, error) {
+	return sqlite3.WithInstance(db, &sqlite3.Config{})
+}
+
+func backup_sqlite_to_file(ctx context.Context, db *sql.DB, path string) error {
+	backupDest, err := create_db(ctx, path)
+	if err != nil {
+		return fmt.Errorf("failed to create backup db: %w", err)
+	}
+	defer backupDest.Close()
+
+	return backup_db(ctx, backupDest, db)
+}
+
+func restore_sqlite_from_file(ctx context.Context, db *sql.DB, path string) error {
+	backup, err := create_db(ctx, "file:"+path+"?mode=ro")
+	if err != nil {
+		return fmt.Errorf("failed to open backup db: %w", err)
+	}
+	defer backup.Close()
+
+	return backup_db(ctx, db, backup)
+}
+
+func backup_db(ctx context.Context, dest, src *sql.DB) error {
+	destConn, err := dest.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to establish connection to backup destination db: %w", err)
+	}
+	defer destConn.Close()
+
+	srcConn, err := src.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to establish connection to backup source db: %w", err)
+	}
+	defer srcConn.Close()
+
+	return destConn.Raw(func(destConn any) error {
+		return srcConn.Raw(func(srcConn any) error {
+			destSQLiteConn, ok := destConn.(*sqlite3_driver.SQLiteConn)
+			if !ok {
+				return fmt.Errorf("can't convert destination connection to SQLiteConn")
+			}
+
+			srcSQLiteConn, ok := srcConn.(*sqlite3_driver.SQLiteConn)
+			if !ok {
+				return fmt.Errorf("can't convert source connection to SQLiteConn")
+			}
+
+			b, err := destSQLiteConn.Backup("main", srcSQLiteConn, "main")
+			if err != nil {
+				return fmt.Errorf("error initializing SQLite backup: %w", err)
+			}
+
+			done, err := b.Step(-1)
+			if !done {
+				return fmt.Errorf("step of -1, but not done")
+			}
+			if err != nil {
+				return fmt.Errorf("error in stepping backup: %w", err)
+			}
+
+			err = b.Finish()
+			if err != nil {
+				return fmt.Errorf("error finishing backup: %w", err)
+			}
+
+			return err
+		})
+	})
+}
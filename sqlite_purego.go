@@ -0,0 +1,79 @@
+//go:build sqlite_purego
+
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/golang-migrate/migrate/v4/database"
+	migrate_sqlite "github.com/golang-migrate/migrate/v4/database/sqlite"
+	"modernc.org/sqlite"
+)
+
+// sqlite through modernc.org/sqlite, which is pure go and can be cross-compiled with CGO_ENABLED=0
+// the driver doesn't know the _journal_mode parameter of mattn/go-sqlite3, pragmas are set with _pragma
+
+const (
+	sqlite_driver_name         = "sqlite"
+	default_sqlite_data_source = "file:ffs.db?_pragma=journal_mode(WAL)"
+)
+
+func sqlite_migration_driver(db *sql.DB) (database.Driver, error) {
+	return migrate_sqlite.WithInstance(db, &migrate_sqlite.Config{})
+}
+
+// the connections of modernc.org/sqlite run the online backup API against a file
+type sqliteBackupConn interface {
+	NewBackup(dstUri string) (*sqlite.Backup, error)
+	NewRestore(srcUri string) (*sqlite.Backup, error)
+}
+
+func backup_sqlite_to_file(ctx context.Context, db *sql.DB, path string) error {
+	return run_sqlite_backup(ctx, db, func(conn sqliteBackupConn) (*sqlite.Backup, error) {
+		return conn.NewBackup(path)
+	})
+}
+
+func restore_sqlite_from_file(ctx context.Context, db *sql.DB, path string) error {
+	return run_sqlite_backup(ctx, db, func(conn sqliteBackupConn) (*sqlite.Backup, error) {
+		return conn.NewRestore(path)
+	})
+}
+
+func run_sqlite_backup(ctx context.Context, db *sql.DB, start func(sqliteBackupConn) (*sqlite.Backup, error)) error {
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to establish connection to db: %w", err)
+	}
+	defer conn.Close()
+
+	return conn.Raw(func(driverConn any) error {
+		backupConn, ok := driverConn.(sqliteBackupConn)
+		if !ok {
+			return fmt.Errorf("can't convert connection to a sqlite connection")
+		}
+
+		b, err := start(backupConn)
+		if err != nil {
+			return fmt.Errorf("error initializing SQLite backup: %w", err)
+		}
+
+		// unlike mattn/go-sqlite3, Step reports whether pages are left
+		more, err := b.Step(-1)
+		if err != nil {
+			b.Finish()
+			return fmt.Errorf("error in stepping backup: %w", err)
+		}
+		if more {
+			b.Finish()
+			return fmt.Errorf("step of -1, but not done")
+		}
+
+		if err := b.Finish(); err != nil {
+			return fmt.Errorf("error finishing backup: %w", err)
+		}
+		return nil
+	})
+}
@@ -7,8 +7,8 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-func statsPageHandler(c *gin.Context) {
-	logger, user, tx, queries, err := getLoggerUserTransactionQueries(c)
+func (app *App) statsPageHandler(c *gin.Context) {
+	logger, user, tx, queries, err := app.getLoggerUserTransactionQueries(c)
 	if err != nil {
 		c.AbortWithError(http.StatusInternalServerError, err)
 		return
@@ -16,7 +16,7 @@ func statsPageHandler(c *gin.Context) {
 	defer tx.Rollback()
 
 	logger.Debug("fetching user playlists", "user", user.ID)
-	playlists, err := queries.GetPlaylistsForUser(c, user.ID)
+	playlists, err := queries.GetPlaylistsForUser(c.Request.Context(), user.ID)
 	if err != nil {
 		c.AbortWithError(http.StatusInternalServerError, fmt.Errorf("error fetching user playlists: %w", err))
 		return
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+
+	"github.com/bafto/FindFavouriteSong/db"
+	"github.com/golang-migrate/migrate/v4/database"
+)
+
+// the database the app is stored in, selected by config.Datasource
+// postgres:// and postgresql:// urls are stored in postgres, everything else is a sqlite data source
+type storageBackend interface {
+	// name of the database/sql driver
+	driverName() string
+	// directory of the migrations in the embedded migrations
+	migrationsDir() string
+	migrationDriver(db *sql.DB) (database.Driver, error)
+	newStore(ctx context.Context, db *sql.DB) (db.Store, error)
+	// the file extension of backups
+	backupSuffix() string
+	backupToFile(ctx context.Context, db *sql.DB, path string) error
+	restoreFromFile(ctx context.Context, db *sql.DB, path string) error
+	verifyBackup(ctx context.Context, path string) error
+	checkpoint(ctx context.Context, db *sql.DB) error
+	vacuum(ctx context.Context, db *sql.DB) error
+}
+
+func new_storage_backend(dsn string) storageBackend {
+	if is_postgres_dsn(dsn) {
+		return postgresStorage{dsn: dsn}
+	}
+	return sqliteStorage{}
+}
+
+func is_postgres_dsn(dsn string) bool {
+	return strings.HasPrefix(dsn, "postgres://") || strings.HasPrefix(dsn, "postgresql://")
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"fmt"
+	"os/exec"
+
+	"github.com/bafto/FindFavouriteSong/db"
+	"github.com/bafto/FindFavouriteSong/db/postgres"
+	"github.com/golang-migrate/migrate/v4/database"
+	migrate_postgres "github.com/golang-migrate/migrate/v4/database/postgres"
+	_ "github.com/lib/pq"
+)
+
+// backups are custom format archives of pg_dump, so pg_dump and pg_restore
+// of the server's major version have to be installed
+type postgresStorage struct {
+	dsn string
+}
+
+func (postgresStorage) driverName() string {
+	return "postgres"
+}
+
+func (postgresStorage) migrationsDir() string {
+	return "sql/postgres/migrations"
+}
+
+func (postgresStorage) migrationDriver(db *sql.DB) (database.Driver, error) {
+	return migrate_postgres.WithInstance(db, &migrate_postgres.Config{})
+}
+
+func (postgresStorage) newStore(ctx context.Context, conn *sql.DB) (db.Store, error) {
+	queries, err := postgres.Prepare(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+	return postgres.NewStore(queries), nil
+}
+
+func (postgresStorage) backupSuffix() string {
+	return ".dump"
+}
+
+func (storage postgresStorage) backupToFile(ctx context.Context, _ *sql.DB, path string) error {
+	return run_pg_tool(ctx, "pg_dump", "--format=custom", "--file="+path, "--dbname="+storage.dsn)
+}
+
+// drops the objects of the backup before recreating them, in a single transaction
+func (storage postgresStorage) restoreFromFile(ctx context.Context, _ *sql.DB, path string) error {
+	return run_pg_tool(ctx, "pg_restore", "--clean", "--if-exists", "--single-transaction", "--dbname="+storage.dsn, path)
+}
+
+// pg_restore can only read the table of contents of a complete archive
+func (postgresStorage) verifyBackup(ctx context.Context, path string) error {
+	if err := run_pg_tool(ctx, "pg_restore", "--list", path); err != nil {
+		return fmt.Errorf("backup %s is corrupt: %w", path, err)
+	}
+	return nil
+}
+
+// postgres checkpoints on its own, this forces one, which needs the pg_checkpoint role
+func (postgresStorage) checkpoint(ctx context.Context, db *sql.DB) error {
+	_, err := db.ExecContext(ctx, "CHECKPOINT")
+	return err
+}
+
+func (postgresStorage) vacuum(ctx context.Context, db *sql.DB) error {
+	_, err := db.ExecContext(ctx, "VACUUM (ANALYZE)")
+	return err
+}
+
+// pg_dump and pg_restore report their errors on stderr
+func run_pg_tool(ctx context.Context, name string, args ...string) error {
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%s failed: %w: %s", name, err, bytes.TrimSpace(stderr.Bytes()))
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/bafto/FindFavouriteSong/db"
+	"github.com/golang-migrate/migrate/v4/database"
+)
+
+// backups are sqlite databases written with the online backup API
+// the driver is chosen at build time, see sqlite_cgo.go and sqlite_purego.go
+type sqliteStorage struct{}
+
+func (sqliteStorage) driverName() string {
+	return sqlite_driver_name
+}
+
+func (sqliteStorage) migrationsDir() string {
+	return "sql/migrations"
+}
+
+func (sqliteStorage) migrationDriver(db *sql.DB) (database.Driver, error) {
+	return sqlite_migration_driver(db)
+}
+
+func (sqliteStorage) newStore(ctx context.Context, conn *sql.DB) (db.Store, error) {
+	queries, err := db.Prepare(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+	return db.NewStore(queries), nil
+}
+
+func (sqliteStorage) backupSuffix() string {
+	return ".db"
+}
+
+// creates or overwrites the database at path with the contents of db
+func (sqliteStorage) backupToFile(ctx context.Context, db *sql.DB, path string) error {
+	if err := backup_sqlite_to_file(ctx, db, path); err != nil {
+		return fmt.Errorf("failed to backup db: %w", err)
+	}
+
+	backupDest, err := create_db(ctx, path)
+	if err != nil {
+		return fmt.Errorf("failed to open backup db: %w", err)
+	}
+	defer backupDest.Close()
+
+	// the backup copies the WAL mode of db, but a single file is easier to move around
+	if _, err := backupDest.ExecContext(ctx, "PRAGMA journal_mode = DELETE"); err != nil {
+		return fmt.Errorf("failed to set journal mode of backup db: %w", err)
+	}
+	return nil
+}
+
+func (sqliteStorage) restoreFromFile(ctx context.Context, db *sql.DB, path string) error {
+	backup, err := create_db(ctx, "file:"+path+"?mode=ro")
+	if err != nil {
+		return fmt.Errorf("failed to open backup db: %w", err)
+	}
+	defer backup.Close()
+
+	version, dirty, err := db_version(sqliteStorage{}, backup)
+	if err != nil {
+		return fmt.Errorf("backup is not a valid database: %w", err)
+	}
+	slog.Info("restoring database from backup", "backup-path", path, "version", version, "dirty", dirty)
+
+	if err := restore_sqlite_from_file(ctx, db, path); err != nil {
+		return fmt.Errorf("failed to restore backup db: %w", err)
+	}
+	return nil
+}
+
+// runs PRAGMA integrity_check on the backup at path
+func (sqliteStorage) verifyBackup(ctx context.Context, path string) error {
+	backup, err := create_db(ctx, "file:"+path+"?mode=ro")
+	if err != nil {
+		return fmt.Errorf("failed to open backup: %w", err)
+	}
+	defer backup.Close()
+
+	rows, err := backup.QueryContext(ctx, "PRAGMA integrity_check")
+	if err != nil {
+		return fmt.Errorf("failed to check integrity of backup: %w", err)
+	}
+	defer rows.Close()
+
+	// a single "ok" row or the problems found
+	var problems []string
+	for rows.Next() {
+		var problem string
+		if err := rows.Scan(&problem); err != nil {
+			return fmt.Errorf("failed to check integrity of backup: %w", err)
+		}
+		if problem != "ok" {
+			problems = append(problems, problem)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to check integrity of backup: %w", err)
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("backup %s is corrupt: %s", path, strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// writes the WAL into the database file
+func (sqliteStorage) checkpoint(ctx context.Context, db *sql.DB) error {
+	_, err := db.ExecContext(ctx, "PRAGMA WAL_CHECKPOINT(TRUNCATE)")
+	return err
+}
+
+// rebuilds the database file to reclaim the space of deleted rows
+func (sqliteStorage) vacuum(ctx context.Context, db *sql.DB) error {
+	_, err := db.ExecContext(ctx, "VACUUM")
+	return err
+}
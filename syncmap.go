@@ -20,6 +20,27 @@ func (m *SyncMap[K, V]) Store(key K, value V) {
 	m.m.Store(key, value)
 }
 
+func (m *SyncMap[K, V]) LoadOrStore(key K, value V) (V, bool) {
+	v, loaded := m.m.LoadOrStore(key, value)
+	return v.(V), loaded
+}
+
 func (m *SyncMap[K, V]) Delete(key K) {
 	m.m.Delete(key)
 }
+
+func (m *SyncMap[K, V]) LoadAndDelete(key K) (V, bool) {
+	var defaultValue V
+
+	v, ok := m.m.LoadAndDelete(key)
+	if ok {
+		return v.(V), ok
+	}
+	return defaultValue, ok
+}
+
+func (m *SyncMap[K, V]) Range(f func(key K, value V) bool) {
+	m.m.Range(func(key, value any) bool {
+		return f(key.(K), value.(V))
+	})
+}
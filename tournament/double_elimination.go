@@ -0,0 +1,71 @@
+package tournament
+
+import (
+	"context"
+
+	"github.com/bafto/FindFavouriteSong/db"
+)
+
+// every item is eliminated after its second loss
+// items without a loss form the winner bracket, items with one loss the loser bracket
+// pairs are only formed inside a bracket, until both brackets are down to one item
+// which then meet in the grand final
+type doubleEliminationTournament struct{}
+
+func (doubleEliminationTournament) RecordMatch(ctx context.Context, queries Store, session *db.Session) error {
+	return queries.EliminateItemsWithLosses(ctx, db.EliminateItemsWithLossesParams{
+		Session: session.ID,
+		Losses:  2,
+	})
+}
+
+func (doubleEliminationTournament) NextPair(ctx context.Context, queries Store, session *db.Session) ([]db.PlaylistItem, *db.PlaylistItem, error) {
+	items, matches, err := getItemsAndMatches(ctx, queries, session)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if pair := bracketPair(items, matches, session); pair != nil {
+		return pair, nil, nil
+	}
+
+	// no bracket has two items left, which did not play in the current round
+	switch len(items) {
+	case 0:
+		return nil, nil, ErrNoItemsLeft
+	case 1:
+		winner := remainingItemToPlaylistItem(items[0])
+		return nil, &winner, nil
+	case 2: // the grand final, if it was not played in the current round yet
+		if pair := nextPairOf(availableIn(items, session), matches, session.CurrentRound); pair != nil {
+			return pair, nil, nil
+		}
+	}
+
+	if err := advanceRound(ctx, queries, session); err != nil {
+		return nil, nil, err
+	}
+	if pair := bracketPair(items, matches, session); pair != nil {
+		return pair, nil, nil
+	}
+	// the grand final
+	return nextPairOf(availableIn(items, session), matches, session.CurrentRound), nil, nil
+}
+
+// returns two items of the same bracket which did not play in the current round yet
+// or nil if there is no such pair
+func bracketPair(items []db.GetRemainingItemsRow, matches []db.Match, session *db.Session) []db.PlaylistItem {
+	var winnerBracket, loserBracket []db.GetRemainingItemsRow
+	for _, item := range availableIn(items, session) {
+		if item.Losses == 0 {
+			winnerBracket = append(winnerBracket, item)
+		} else {
+			loserBracket = append(loserBracket, item)
+		}
+	}
+
+	if pair := nextPairOf(winnerBracket, matches, session.CurrentRound); pair != nil {
+		return pair
+	}
+	return nextPairOf(loserBracket, matches, session.CurrentRound)
+}
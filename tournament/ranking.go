@@ -0,0 +1,133 @@
+package tournament
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/bafto/FindFavouriteSong/db"
+)
+
+// no item is eliminated, instead all items are sorted by a bottom-up merge sort
+// which uses the matches of the session as comparisons
+// every merge pass is one round, when the sort is complete the full ranking
+// is stored in the ranking table and the first item is the winner
+type rankingTournament struct{}
+
+func (rankingTournament) RecordMatch(ctx context.Context, queries Store, session *db.Session) error {
+	return nil
+}
+
+func (rankingTournament) NextPair(ctx context.Context, queries Store, session *db.Session) ([]db.PlaylistItem, *db.PlaylistItem, error) {
+	items, err := queries.GetRemainingItems(ctx, session.ID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error getting remaining items from DB: %w", err)
+	}
+	if len(items) == 0 {
+		return nil, nil, ErrNoItemsLeft
+	}
+
+	matches, err := queries.GetMatchesForSession(ctx, session.ID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error getting matches from DB: %w", err)
+	}
+
+	itemsById := make(map[string]db.GetRemainingItemsRow, len(items))
+	ids := make([]string, 0, len(items))
+	// the sort has to start from the same order on every request
+	for _, item := range shuffled(items, session.RandomSeed, 0) {
+		itemsById[item.ID] = item
+		ids = append(ids, item.ID)
+	}
+
+	sorted, pair, pass := mergeSortStep(ids, matchResults(matches))
+	if sorted == nil {
+		// the pair belongs to the merge pass after the completed ones
+		for session.CurrentRound < pass {
+			if err := advanceRound(ctx, queries, session); err != nil {
+				return nil, nil, err
+			}
+		}
+		return []db.PlaylistItem{
+			remainingItemToPlaylistItem(itemsById[pair[0]]),
+			remainingItemToPlaylistItem(itemsById[pair[1]]),
+		}, nil, nil
+	}
+
+	for i, id := range sorted {
+		if err := queries.AddOrUpdateRankingItem(ctx, db.AddOrUpdateRankingItemParams{
+			Session:      session.ID,
+			PlaylistItem: id,
+			Position:     int64(i + 1),
+		}); err != nil {
+			return nil, nil, fmt.Errorf("could not insert ranking into db: %w", err)
+		}
+	}
+
+	winner := remainingItemToPlaylistItem(itemsById[sorted[0]])
+	return nil, &winner, nil
+}
+
+// maps both orders of every pair that played to the winner of their last match
+// ties keep the order in which the items were presented
+// skips are no result, but they are only recorded in sessions of other modes
+func matchResults(matches []db.Match) map[[2]string]string {
+	results := make(map[[2]string]string, len(matches)*2)
+	for _, match := range matches {
+		if match.Outcome == OutcomeSkip {
+			continue
+		}
+		results[[2]string{match.Winner, match.Loser}] = match.Winner
+		results[[2]string{match.Loser, match.Winner}] = match.Winner
+	}
+	return results
+}
+
+// replays a bottom-up merge sort of ids using results as comparisons
+// returns the sorted ids (best first) if all needed comparisons are known
+// otherwise nil and the first pair which still has to be compared
+// pass is the number of completed merge passes
+func mergeSortStep(ids []string, results map[[2]string]string) (sorted []string, pair [2]string, pass int64) {
+	runs := make([][]string, len(ids))
+	for i, id := range ids {
+		runs[i] = []string{id}
+	}
+
+	for ; len(runs) > 1; pass++ {
+		merged := make([][]string, 0, (len(runs)+1)/2)
+		for i := 0; i+1 < len(runs); i += 2 {
+			run, missing, ok := mergeRuns(runs[i], runs[i+1], results)
+			if !ok {
+				return nil, missing, pass
+			}
+			merged = append(merged, run)
+		}
+		if len(runs)%2 == 1 {
+			merged = append(merged, runs[len(runs)-1])
+		}
+		runs = merged
+	}
+
+	return runs[0], pair, pass
+}
+
+func mergeRuns(a, b []string, results map[[2]string]string) ([]string, [2]string, bool) {
+	run := make([]string, 0, len(a)+len(b))
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		winner, ok := results[[2]string{a[i], b[j]}]
+		if !ok {
+			return nil, [2]string{a[i], b[j]}, false
+		}
+
+		if winner == a[i] {
+			run = append(run, a[i])
+			i++
+		} else {
+			run = append(run, b[j])
+			j++
+		}
+	}
+	run = append(run, a[i:]...)
+	run = append(run, b[j:]...)
+	return run, [2]string{}, true
+}
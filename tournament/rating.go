@@ -0,0 +1,97 @@
+package tournament
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/bafto/FindFavouriteSong/db"
+)
+
+const (
+	// the rating of items which were not rated yet
+	DefaultRating = 1500.0
+	rating_k      = 32.0
+)
+
+// updates the elo ratings of winner and loser for the given user
+// outcome must be OutcomeWin or OutcomeTie
+// returns the rating delta, which is to be stored with the match
+func updateRatings(ctx context.Context, queries Store, user, winner, loser, outcome string) (float64, error) {
+	winnerRating, err := getRating(ctx, queries, user, winner)
+	if err != nil {
+		return 0, err
+	}
+	loserRating, err := getRating(ctx, queries, user, loser)
+	if err != nil {
+		return 0, err
+	}
+
+	score := 1.0
+	if outcome == OutcomeTie {
+		score = 0.5
+	}
+
+	delta := rating_k * (score - expectedScore(winnerRating.Rating, loserRating.Rating))
+	return delta, applyRatingDelta(ctx, queries, winnerRating, loserRating, delta, 1)
+}
+
+// reverts the rating change of a match which was recorded by updateRatings
+func revertRatings(ctx context.Context, queries Store, user string, match db.Match) error {
+	// the match was played before ratings existed or was skipped
+	if !match.RatingDelta.Valid {
+		return nil
+	}
+
+	winnerRating, err := getRating(ctx, queries, user, match.Winner)
+	if err != nil {
+		return err
+	}
+	loserRating, err := getRating(ctx, queries, user, match.Loser)
+	if err != nil {
+		return err
+	}
+
+	return applyRatingDelta(ctx, queries, winnerRating, loserRating, -match.RatingDelta.Float64, -1)
+}
+
+func applyRatingDelta(ctx context.Context, queries Store, winnerRating, loserRating db.Rating, delta float64, matches int64) error {
+	winnerRating.Rating += delta
+	loserRating.Rating -= delta
+	winnerRating.Matches += matches
+	loserRating.Matches += matches
+
+	for _, rating := range []db.Rating{winnerRating, loserRating} {
+		if err := queries.AddOrUpdateRating(ctx, db.AddOrUpdateRatingParams{
+			User:         rating.User,
+			PlaylistItem: rating.PlaylistItem,
+			Rating:       rating.Rating,
+			Matches:      rating.Matches,
+		}); err != nil {
+			return fmt.Errorf("could not update rating in db: %w", err)
+		}
+	}
+	return nil
+}
+
+// returns the stored rating or the default rating if the item was not rated yet
+func getRating(ctx context.Context, queries Store, user, item string) (db.Rating, error) {
+	rating, err := queries.GetRating(ctx, db.GetRatingParams{
+		User:         user,
+		PlaylistItem: item,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return db.Rating{User: user, PlaylistItem: item, Rating: DefaultRating}, nil
+	}
+	if err != nil {
+		return rating, fmt.Errorf("could not load rating from db: %w", err)
+	}
+	return rating, nil
+}
+
+// the probability of a winning against b
+func expectedScore(a, b float64) float64 {
+	return 1 / (1 + math.Pow(10, (b-a)/400))
+}
@@ -0,0 +1,100 @@
+package tournament
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/bafto/FindFavouriteSong/db"
+)
+
+// problems which are detected and repaired by Repair
+const (
+	ProblemMissingItems      = "possible_next_items are missing"
+	ProblemInconsistentItems = "possible_next_items do not match the matches"
+	ProblemNoItemsLeft       = "all items were eliminated"
+	ProblemWinnerNotSet      = "the session was decided but the winner was never set"
+)
+
+// checks the items and the winner of an undecided session for broken states and repairs them
+// all changes are made through queries, so the caller decides whether
+// the repairs are kept by committing or rolling back its transaction
+// this includes the changes of the NextPair of the mode, like advancing the round,
+// so a check without repairing has to roll back too
+// returns the problems that were found, all of them are repaired if err is nil
+func Repair(ctx context.Context, queries Store, session *db.Session) ([]string, error) {
+	var problems []string
+	if session.Winner.Valid {
+		return problems, nil
+	}
+
+	tournament, err := ForMode(session.Mode)
+	if err != nil {
+		return problems, err
+	}
+
+	count, err := queries.CountPossibleNextItemsForSession(ctx, session.ID)
+	if err != nil {
+		return problems, fmt.Errorf("could not count possible_next_items: %w", err)
+	}
+	if count == 0 {
+		problems = append(problems, ProblemMissingItems)
+		if err := queries.InitializePossibleNextItemsForSession(ctx, db.InitializePossibleNextItemsForSessionParams{
+			Session:  session.ID,
+			Playlist: session.Playlist,
+		}); err != nil {
+			return problems, fmt.Errorf("could not initialize possible_next_items: %w", err)
+		}
+	}
+
+	// recompute the possible_next_items from the matches and compare them to the stored ones
+	before, err := queries.GetRemainingItems(ctx, session.ID)
+	if err != nil {
+		return problems, fmt.Errorf("error getting remaining items from DB: %w", err)
+	}
+	if err := queries.ResetPossibleNextItemsForSession(ctx, session.ID); err != nil {
+		return problems, fmt.Errorf("could not reset possible_next_items: %w", err)
+	}
+	if err := tournament.RecordMatch(ctx, queries, session); err != nil {
+		return problems, fmt.Errorf("could not update possible_next_items: %w", err)
+	}
+	after, err := queries.GetRemainingItems(ctx, session.ID)
+	if err != nil {
+		return problems, fmt.Errorf("error getting remaining items from DB: %w", err)
+	}
+	if count != 0 && !slices.Equal(before, after) {
+		problems = append(problems, ProblemInconsistentItems)
+	}
+
+	// contradicting matches can eliminate every item,
+	// in that case the items with the fewest losses play on
+	if len(after) == 0 {
+		problems = append(problems, ProblemNoItemsLeft)
+		if err := queries.ReviveItemsWithFewestLosses(ctx, session.ID); err != nil {
+			return problems, fmt.Errorf("could not revive items: %w", err)
+		}
+	}
+
+	_, winner, err := tournament.NextPair(ctx, queries, session)
+	if errors.Is(err, ErrNoItemsLeft) {
+		return problems, fmt.Errorf("session has no items: %w", err)
+	}
+	if err != nil {
+		return problems, fmt.Errorf("error getting next pair: %w", err)
+	}
+
+	// the session was decided, but the winner was never stored
+	if winner != nil {
+		problems = append(problems, ProblemWinnerNotSet)
+		if err := queries.SetWinner(ctx, db.SetWinnerParams{
+			Winner: notNull(winner.ID),
+			ID:     session.ID,
+		}); err != nil {
+			return problems, fmt.Errorf("failed to set winner in DB: %w", err)
+		}
+		session.Winner = notNull(winner.ID)
+	}
+
+	return problems, nil
+}
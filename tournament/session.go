@@ -0,0 +1,170 @@
+package tournament
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/bafto/FindFavouriteSong/db"
+)
+
+// returned by UndoLatestMatch if the session has no matches
+var ErrNoMatch = errors.New("no match to undo")
+
+// returned by RecordMatch if the outcome can't be recorded in the mode of the session
+var ErrInvalidOutcome = errors.New("invalid outcome")
+
+// a ranking needs a result for every pair it asks for, so its pairs can't be skipped
+func IsValidOutcome(mode, outcome string) bool {
+	switch outcome {
+	case OutcomeWin, OutcomeTie:
+		return true
+	case OutcomeSkip:
+		return mode != ModeRanking
+	default:
+		return false
+	}
+}
+
+// creates a session of params.Playlist for params.User and returns its id
+// seeds are item ids, the strongest first, they are paired strongest against weakest
+// without seeds the items are paired randomly by params.RandomSeed
+func CreateSession(ctx context.Context, queries Store, params db.AddSessionParams, seeds []string) (int64, error) {
+	if _, err := ForMode(params.Mode); err != nil {
+		return 0, err
+	}
+
+	sessionID, err := queries.AddSession(ctx, params)
+	if err != nil {
+		return 0, fmt.Errorf("could not insert session into db: %w", err)
+	}
+
+	if err := queries.InitializePossibleNextItemsForSession(ctx, db.InitializePossibleNextItemsForSessionParams{
+		Session:  sessionID,
+		Playlist: params.Playlist,
+	}); err != nil {
+		return 0, fmt.Errorf("could not initialize possible_next_items: %w", err)
+	}
+
+	for i, item := range seeds {
+		if err := queries.SetSeed(ctx, db.SetSeedParams{
+			Seed:         sql.NullInt64{Int64: int64(i + 1), Valid: true},
+			Session:      sessionID,
+			PlaylistItem: item,
+		}); err != nil {
+			return 0, fmt.Errorf("could not set seed in db: %w", err)
+		}
+	}
+	return sessionID, nil
+}
+
+// stores the decision on a pair as a match of the session and updates the
+// ratings of the user the session belongs to
+func RecordMatch(ctx context.Context, queries Store, session *db.Session, winnerID, loserID, outcome string) error {
+	tournament, err := ForMode(session.Mode)
+	if err != nil {
+		return err
+	}
+	if !IsValidOutcome(session.Mode, outcome) {
+		return fmt.Errorf("%w %s in %s session", ErrInvalidOutcome, outcome, session.Mode)
+	}
+
+	ratingDelta := sql.NullFloat64{}
+	if outcome != OutcomeSkip {
+		delta, err := updateRatings(ctx, queries, session.User, winnerID, loserID, outcome)
+		if err != nil {
+			return fmt.Errorf("could not update ratings: %w", err)
+		}
+		ratingDelta = sql.NullFloat64{Float64: delta, Valid: true}
+	}
+
+	if err := queries.AddMatch(ctx, db.AddMatchParams{
+		Session:     session.ID,
+		RoundNumber: session.CurrentRound,
+		Winner:      winnerID,
+		Loser:       loserID,
+		RatingDelta: ratingDelta,
+		Outcome:     outcome,
+	}); err != nil {
+		return fmt.Errorf("could not create match in db: %w", err)
+	}
+
+	if err := tournament.RecordMatch(ctx, queries, session); err != nil {
+		return fmt.Errorf("could not record match: %w", err)
+	}
+	return nil
+}
+
+// returns the next pair to be decided, advancing session.CurrentRound if necessary
+// if the session is decided, its winner is stored and returned instead of a pair
+// ErrNoItemsLeft is returned for broken sessions
+func NextPair(ctx context.Context, queries Store, session *db.Session) ([]db.PlaylistItem, *db.PlaylistItem, error) {
+	tournament, err := ForMode(session.Mode)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	pair, winner, err := tournament.NextPair(ctx, queries, session)
+	if err != nil || winner == nil {
+		return pair, winner, err
+	}
+
+	if err := queries.SetWinner(ctx, db.SetWinnerParams{
+		Winner: notNull(winner.ID),
+		ID:     session.ID,
+	}); err != nil {
+		return nil, nil, fmt.Errorf("failed to set winner in DB: %w", err)
+	}
+	session.Winner = notNull(winner.ID)
+	return nil, winner, nil
+}
+
+// deletes the latest match of session and reverts its effects
+// the pair of the returned match is to be decided again
+func UndoLatestMatch(ctx context.Context, queries Store, session *db.Session) (db.Match, error) {
+	tournament, err := ForMode(session.Mode)
+	if err != nil {
+		return db.Match{}, err
+	}
+
+	match, err := queries.GetLatestMatchForSession(ctx, session.ID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return db.Match{}, ErrNoMatch
+	}
+	if err != nil {
+		return db.Match{}, fmt.Errorf("could not load latest match from DB: %w", err)
+	}
+
+	if err := queries.DeleteMatch(ctx, match.ID); err != nil {
+		return db.Match{}, fmt.Errorf("could not delete match from DB: %w", err)
+	}
+
+	if err := queries.ResetPossibleNextItemsForSession(ctx, session.ID); err != nil {
+		return db.Match{}, fmt.Errorf("could not reset possible_next_items: %w", err)
+	}
+
+	if err := tournament.RecordMatch(ctx, queries, session); err != nil {
+		return db.Match{}, fmt.Errorf("could not update possible_next_items: %w", err)
+	}
+
+	if err := revertRatings(ctx, queries, session.User, match); err != nil {
+		return db.Match{}, fmt.Errorf("could not revert ratings: %w", err)
+	}
+
+	// the round might have been advanced after the match
+	if session.CurrentRound != match.RoundNumber {
+		if err := queries.SetCurrentRound(ctx, db.SetCurrentRoundParams{
+			ID:           session.ID,
+			CurrentRound: match.RoundNumber,
+		}); err != nil {
+			return db.Match{}, fmt.Errorf("error updating current_round in DB: %w", err)
+		}
+		session.CurrentRound = match.RoundNumber
+	}
+	return match, nil
+}
+
+func notNull(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: true}
+}
@@ -0,0 +1,253 @@
+package tournament
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+	"testing"
+
+	"github.com/bafto/FindFavouriteSong/db"
+)
+
+const (
+	test_user     = "user"
+	test_playlist = "playlist"
+	test_seed     = 42
+)
+
+var testItemIDs = []string{"a", "b", "c", "d", "e", "f", "g", "h"}
+
+// a store with a session of mode on a playlist of testItemIDs
+func newTestSession(t *testing.T, mode string, rounds int64) (*fakeStore, *db.Session) {
+	t.Helper()
+	store := newFakeStore()
+	store.playlists[test_playlist] = testItemIDs
+
+	id, err := CreateSession(context.Background(), store, db.AddSessionParams{
+		Playlist:   test_playlist,
+		User:       test_user,
+		Mode:       mode,
+		Rounds:     rounds,
+		RandomSeed: test_seed,
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	session := *store.sessions[id]
+	return store, &session
+}
+
+// decides every pair for the item which comes first alphabetically
+func decide(pair []db.PlaylistItem) (winner, loser string) {
+	if pair[0].ID < pair[1].ID {
+		return pair[0].ID, pair[1].ID
+	}
+	return pair[1].ID, pair[0].ID
+}
+
+// plays the session until it is decided, see decide
+// returns the rounds the pairs were decided in and the winner
+func playSession(t *testing.T, store *fakeStore, session *db.Session) ([]int64, string) {
+	t.Helper()
+	ctx := context.Background()
+
+	var rounds []int64
+	for range 100 {
+		pair, winner, err := NextPair(ctx, store, session)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if winner != nil {
+			return rounds, winner.ID
+		}
+
+		// asking again, e.g. after a reload, neither changes the pair nor the round
+		again, _, err := NextPair(ctx, store, session)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if pairIDs(again) != pairIDs(pair) || store.sessions[session.ID].CurrentRound != session.CurrentRound {
+			t.Fatalf("asking for the pair %v in round %d again returned %v in round %d",
+				pairIDs(pair), session.CurrentRound, pairIDs(again), store.sessions[session.ID].CurrentRound)
+		}
+
+		rounds = append(rounds, session.CurrentRound)
+		winnerID, loserID := decide(pair)
+		if err := RecordMatch(ctx, store, session, winnerID, loserID, OutcomeWin); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Fatal("session was not decided after 100 matches")
+	return nil, ""
+}
+
+func TestRoundsOfEachMode(t *testing.T) {
+	tests := []struct {
+		mode   string
+		rounds int64
+		want   []int64 // the round of every match
+	}{
+		{mode: ModeKnockout, want: []int64{0, 0, 0, 0, 1, 1, 2}},
+		{mode: ModeDoubleElimination, want: []int64{0, 0, 0, 0, 1, 1, 1, 1, 2, 2, 2, 3, 4, 5}},
+		{mode: ModeSwiss, want: []int64{0, 0, 0, 0, 1, 1, 1, 1, 2, 2, 2, 2}},
+		{mode: ModeSwiss, rounds: 1, want: []int64{0, 0, 0, 0}},
+		{mode: ModeRanking, want: []int64{0, 0, 0, 0, 1, 1, 1, 1, 2, 2, 2, 2, 2}},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("%s with %d rounds", test.mode, test.rounds), func(t *testing.T) {
+			store, session := newTestSession(t, test.mode, test.rounds)
+
+			rounds, winner := playSession(t, store, session)
+			if !slices.Equal(rounds, test.want) {
+				t.Errorf("expected matches in rounds %v, got %v", test.want, rounds)
+			}
+			if winner != "a" {
+				t.Errorf("expected a to win, got %s", winner)
+			}
+			if stored := store.sessions[session.ID]; stored.CurrentRound != session.CurrentRound || stored.Winner.String != winner {
+				t.Errorf("stored session %+v does not match %+v", *stored, *session)
+			}
+			// deciding the session is no new round
+			if last := test.want[len(test.want)-1]; session.CurrentRound != last {
+				t.Errorf("expected the session to end in round %d, got %d", last, session.CurrentRound)
+			}
+		})
+	}
+}
+
+func TestRankingIsStored(t *testing.T) {
+	store, session := newTestSession(t, ModeRanking, 0)
+	playSession(t, store, session)
+
+	if ranking := store.rankingOf(session.ID); !slices.Equal(ranking, testItemIDs) {
+		t.Errorf("expected ranking %v, got %v", testItemIDs, ranking)
+	}
+}
+
+// the state of a session which UndoLatestMatch restores
+type sessionState struct {
+	round   int64
+	items   []db.GetRemainingItemsRow
+	matches []db.Match
+	ratings map[string]db.Rating
+}
+
+func stateOf(t *testing.T, store *fakeStore, session *db.Session) sessionState {
+	t.Helper()
+	items, err := store.GetRemainingItems(context.Background(), session.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	matches, err := store.GetMatchesForSession(context.Background(), session.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ratings := map[string]db.Rating{}
+	for _, id := range testItemIDs {
+		rating, err := getRating(context.Background(), store, test_user, id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ratings[id] = rating
+	}
+	return sessionState{round: store.sessions[session.ID].CurrentRound, items: items, matches: matches, ratings: ratings}
+}
+
+func (state sessionState) equal(other sessionState) bool {
+	return state.round == other.round &&
+		slices.Equal(state.items, other.items) &&
+		slices.Equal(state.matches, other.matches) &&
+		maps.EqualFunc(state.ratings, other.ratings, func(a, b db.Rating) bool {
+			// reverting a rating delta is exact up to rounding
+			return a.Matches == b.Matches && math.Abs(a.Rating-b.Rating) < 1e-9
+		})
+}
+
+// every match of a session can be undone, after which the same pair is to be decided again
+func TestUndoLatestMatchRestoresSession(t *testing.T) {
+	for _, mode := range []string{ModeKnockout, ModeDoubleElimination, ModeSwiss, ModeRanking} {
+		t.Run(mode, func(t *testing.T) {
+			ctx := context.Background()
+			store, session := newTestSession(t, mode, 0)
+
+			for range 100 {
+				pair, winner, err := NextPair(ctx, store, session)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if winner != nil {
+					return
+				}
+				before := stateOf(t, store, session)
+
+				// the loser wins the match which is undone
+				loserID, winnerID := decide(pair)
+				if err := RecordMatch(ctx, store, session, winnerID, loserID, OutcomeWin); err != nil {
+					t.Fatal(err)
+				}
+				match, err := UndoLatestMatch(ctx, store, session)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if match.Winner != winnerID || match.Loser != loserID {
+					t.Errorf("undid %s against %s, expected %s against %s", match.Winner, match.Loser, winnerID, loserID)
+				}
+				if after := stateOf(t, store, session); !before.equal(after) {
+					t.Fatalf("undoing the match did not restore the session:\nbefore %+v\nafter  %+v", before, after)
+				}
+
+				again, _, err := NextPair(ctx, store, session)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if pairIDs(again) != pairIDs(pair) {
+					t.Fatalf("expected pair %v after the undo, got %v", pairIDs(pair), pairIDs(again))
+				}
+
+				winnerID, loserID = decide(pair)
+				if err := RecordMatch(ctx, store, session, winnerID, loserID, OutcomeWin); err != nil {
+					t.Fatal(err)
+				}
+			}
+			t.Fatal("session was not decided after 100 matches")
+		})
+	}
+}
+
+func TestUndoWithoutMatches(t *testing.T) {
+	store, session := newTestSession(t, ModeKnockout, 0)
+	if _, err := UndoLatestMatch(context.Background(), store, session); !errors.Is(err, ErrNoMatch) {
+		t.Errorf("expected ErrNoMatch, got %v", err)
+	}
+}
+
+func TestSkipInRanking(t *testing.T) {
+	ctx := context.Background()
+	store, session := newTestSession(t, ModeRanking, 0)
+
+	pair, _, err := NextPair(ctx, store, session)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the skipped pair would be asked for again, so the ranking would never progress
+	if err := RecordMatch(ctx, store, session, pair[0].ID, pair[1].ID, OutcomeSkip); !errors.Is(err, ErrInvalidOutcome) {
+		t.Errorf("expected ErrInvalidOutcome, got %v", err)
+	}
+	if matches, _ := store.GetMatchesForSession(ctx, session.ID); len(matches) != 0 {
+		t.Errorf("expected no matches, got %v", matches)
+	}
+
+	// other modes pair both items again later
+	store, session = newTestSession(t, ModeKnockout, 0)
+	if pair, _, err = NextPair(ctx, store, session); err != nil {
+		t.Fatal(err)
+	}
+	if err := RecordMatch(ctx, store, session, pair[0].ID, pair[1].ID, OutcomeSkip); err != nil {
+		t.Errorf("expected the skip to be recorded, got %v", err)
+	}
+}
@@ -0,0 +1,40 @@
+package tournament
+
+import (
+	"context"
+
+	"github.com/bafto/FindFavouriteSong/db"
+)
+
+// the queries the tournament logic needs
+// db.Store implements it, other storages have to behave like the queries in sql/queries
+type Store interface {
+	// sessions
+	AddSession(ctx context.Context, arg db.AddSessionParams) (int64, error)
+	SetCurrentRound(ctx context.Context, arg db.SetCurrentRoundParams) error
+	SetWinner(ctx context.Context, arg db.SetWinnerParams) error
+
+	// the items which are still in the tournament
+	InitializePossibleNextItemsForSession(ctx context.Context, arg db.InitializePossibleNextItemsForSessionParams) error
+	CountPossibleNextItemsForSession(ctx context.Context, session int64) (int64, error)
+	GetRemainingItems(ctx context.Context, session int64) ([]db.GetRemainingItemsRow, error)
+	SetSeed(ctx context.Context, arg db.SetSeedParams) error
+	EliminateItemsWithLosses(ctx context.Context, arg db.EliminateItemsWithLossesParams) error
+	ReviveItemsWithFewestLosses(ctx context.Context, session int64) error
+	ResetPossibleNextItemsForSession(ctx context.Context, session int64) error
+
+	// matches
+	AddMatch(ctx context.Context, arg db.AddMatchParams) error
+	DeleteMatch(ctx context.Context, id int64) error
+	GetMatchesForSession(ctx context.Context, session int64) ([]db.Match, error)
+	GetLatestMatchForSession(ctx context.Context, session int64) (db.Match, error)
+
+	// ranking sessions
+	AddOrUpdateRankingItem(ctx context.Context, arg db.AddOrUpdateRankingItemParams) error
+
+	// ratings of the user a session belongs to
+	GetRating(ctx context.Context, arg db.GetRatingParams) (db.Rating, error)
+	AddOrUpdateRating(ctx context.Context, arg db.AddOrUpdateRatingParams) error
+}
+
+var _ Store = db.Querier(nil)
@@ -0,0 +1,245 @@
+package tournament
+
+import (
+	"context"
+	"database/sql"
+	"maps"
+	"slices"
+	"sort"
+
+	"github.com/bafto/FindFavouriteSong/db"
+)
+
+// an in-memory Store which behaves like the queries and triggers in sql/
+// it is not safe for concurrent use
+type fakeStore struct {
+	playlists map[string][]string // item ids by playlist
+	sessions  map[int64]*db.Session
+	items     map[int64]map[string]*fakeItem // the possible_next_items by session and item
+	matches   []db.Match                     // ordered by id
+	ratings   map[[2]string]db.Rating        // by user and item
+	ranking   map[int64]map[string]int64     // positions by session and item
+
+	lastSessionID int64
+	lastMatchID   int64
+}
+
+// a row of possible_next_items
+type fakeItem struct {
+	lost        bool
+	wins        int64
+	losses      int64
+	playedRound int64
+	seed        sql.NullInt64
+}
+
+var _ Store = (*fakeStore)(nil)
+
+func newFakeStore() *fakeStore {
+	return &fakeStore{
+		playlists: map[string][]string{},
+		sessions:  map[int64]*db.Session{},
+		items:     map[int64]map[string]*fakeItem{},
+		ratings:   map[[2]string]db.Rating{},
+		ranking:   map[int64]map[string]int64{},
+	}
+}
+
+func (store *fakeStore) AddSession(ctx context.Context, arg db.AddSessionParams) (int64, error) {
+	store.lastSessionID++
+	store.sessions[store.lastSessionID] = &db.Session{
+		ID:         store.lastSessionID,
+		Playlist:   arg.Playlist,
+		User:       arg.User,
+		Mode:       arg.Mode,
+		Rounds:     arg.Rounds,
+		RandomSeed: arg.RandomSeed,
+	}
+	return store.lastSessionID, nil
+}
+
+func (store *fakeStore) SetCurrentRound(ctx context.Context, arg db.SetCurrentRoundParams) error {
+	if session, ok := store.sessions[arg.ID]; ok {
+		session.CurrentRound = arg.CurrentRound
+	}
+	return nil
+}
+
+// like the won_trigger, which deletes the possible_next_items of the session
+func (store *fakeStore) SetWinner(ctx context.Context, arg db.SetWinnerParams) error {
+	if session, ok := store.sessions[arg.ID]; ok {
+		session.Winner = arg.Winner
+	}
+	delete(store.items, arg.ID)
+	return nil
+}
+
+func (store *fakeStore) InitializePossibleNextItemsForSession(ctx context.Context, arg db.InitializePossibleNextItemsForSessionParams) error {
+	items := map[string]*fakeItem{}
+	for _, id := range store.playlists[arg.Playlist] {
+		items[id] = &fakeItem{playedRound: -1}
+	}
+	store.items[arg.Session] = items
+	return nil
+}
+
+func (store *fakeStore) CountPossibleNextItemsForSession(ctx context.Context, session int64) (int64, error) {
+	return int64(len(store.items[session])), nil
+}
+
+func (store *fakeStore) GetRemainingItems(ctx context.Context, session int64) ([]db.GetRemainingItemsRow, error) {
+	var rows []db.GetRemainingItemsRow
+	for _, id := range slices.Sorted(maps.Keys(store.items[session])) {
+		item := store.items[session][id]
+		if item.lost {
+			continue
+		}
+		rows = append(rows, db.GetRemainingItemsRow{
+			ID:          id,
+			Title:       sql.NullString{String: id, Valid: true},
+			Wins:        item.wins,
+			Losses:      item.losses,
+			PlayedRound: item.playedRound,
+			Seed:        item.seed,
+		})
+	}
+	return rows, nil
+}
+
+func (store *fakeStore) SetSeed(ctx context.Context, arg db.SetSeedParams) error {
+	if item, ok := store.items[arg.Session][arg.PlaylistItem]; ok {
+		item.seed = arg.Seed
+	}
+	return nil
+}
+
+func (store *fakeStore) EliminateItemsWithLosses(ctx context.Context, arg db.EliminateItemsWithLossesParams) error {
+	for _, item := range store.items[arg.Session] {
+		if item.losses >= arg.Losses {
+			item.lost = true
+		}
+	}
+	return nil
+}
+
+func (store *fakeStore) ReviveItemsWithFewestLosses(ctx context.Context, session int64) error {
+	fewest := int64(-1)
+	for _, item := range store.items[session] {
+		if fewest == -1 || item.losses < fewest {
+			fewest = item.losses
+		}
+	}
+	for _, item := range store.items[session] {
+		if item.losses == fewest {
+			item.lost = false
+		}
+	}
+	return nil
+}
+
+// recomputes the possible_next_items from the matches
+func (store *fakeStore) ResetPossibleNextItemsForSession(ctx context.Context, session int64) error {
+	for id, item := range store.items[session] {
+		*item = fakeItem{playedRound: -1, seed: item.seed}
+		for _, match := range store.matches {
+			if match.Session != session || (match.Winner != id && match.Loser != id) {
+				continue
+			}
+			store.applyMatch(item, id, match)
+		}
+	}
+	return nil
+}
+
+// like the insert_match_trigger and insert_tie_match_trigger, skips change nothing
+func (store *fakeStore) applyMatch(item *fakeItem, id string, match db.Match) {
+	switch match.Outcome {
+	case OutcomeWin:
+		if match.Winner == id {
+			item.wins++
+		} else {
+			item.losses++
+		}
+		item.playedRound = match.RoundNumber
+	case OutcomeTie:
+		item.playedRound = match.RoundNumber
+	}
+}
+
+func (store *fakeStore) AddMatch(ctx context.Context, arg db.AddMatchParams) error {
+	store.lastMatchID++
+	match := db.Match{
+		ID:          store.lastMatchID,
+		Session:     arg.Session,
+		RoundNumber: arg.RoundNumber,
+		Winner:      arg.Winner,
+		Loser:       arg.Loser,
+		RatingDelta: arg.RatingDelta,
+		Outcome:     arg.Outcome,
+	}
+	store.matches = append(store.matches, match)
+
+	for _, id := range []string{match.Winner, match.Loser} {
+		if item, ok := store.items[match.Session][id]; ok {
+			store.applyMatch(item, id, match)
+		}
+	}
+	return nil
+}
+
+func (store *fakeStore) DeleteMatch(ctx context.Context, id int64) error {
+	store.matches = slices.DeleteFunc(store.matches, func(match db.Match) bool {
+		return match.ID == id
+	})
+	return nil
+}
+
+func (store *fakeStore) GetMatchesForSession(ctx context.Context, session int64) ([]db.Match, error) {
+	var matches []db.Match
+	for _, match := range store.matches {
+		if match.Session == session {
+			matches = append(matches, match)
+		}
+	}
+	return matches, nil
+}
+
+func (store *fakeStore) GetLatestMatchForSession(ctx context.Context, session int64) (db.Match, error) {
+	for i := len(store.matches) - 1; i >= 0; i-- {
+		if store.matches[i].Session == session {
+			return store.matches[i], nil
+		}
+	}
+	return db.Match{}, sql.ErrNoRows
+}
+
+func (store *fakeStore) AddOrUpdateRankingItem(ctx context.Context, arg db.AddOrUpdateRankingItemParams) error {
+	if store.ranking[arg.Session] == nil {
+		store.ranking[arg.Session] = map[string]int64{}
+	}
+	store.ranking[arg.Session][arg.PlaylistItem] = arg.Position
+	return nil
+}
+
+func (store *fakeStore) GetRating(ctx context.Context, arg db.GetRatingParams) (db.Rating, error) {
+	rating, ok := store.ratings[[2]string{arg.User, arg.PlaylistItem}]
+	if !ok {
+		return db.Rating{}, sql.ErrNoRows
+	}
+	return rating, nil
+}
+
+func (store *fakeStore) AddOrUpdateRating(ctx context.Context, arg db.AddOrUpdateRatingParams) error {
+	store.ratings[[2]string{arg.User, arg.PlaylistItem}] = db.Rating(arg)
+	return nil
+}
+
+// the ranking of session, best first
+func (store *fakeStore) rankingOf(session int64) []string {
+	positions := store.ranking[session]
+	ids := slices.Collect(maps.Keys(positions))
+	sort.Slice(ids, func(i, j int) bool {
+		return positions[ids[i]] < positions[ids[j]]
+	})
+	return ids
+}
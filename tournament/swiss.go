@@ -0,0 +1,109 @@
+package tournament
+
+import (
+	"context"
+	"math"
+	"sort"
+
+	"github.com/bafto/FindFavouriteSong/db"
+)
+
+// no item is eliminated, instead every item plays once per round
+// against an item with a similar number of wins it did not meet yet
+// after session.Rounds rounds the item with the most wins is the winner
+// with an odd number of items one item sits out each round
+type swissTournament struct{}
+
+func (swissTournament) RecordMatch(ctx context.Context, queries Store, session *db.Session) error {
+	return nil
+}
+
+func (swissTournament) NextPair(ctx context.Context, queries Store, session *db.Session) ([]db.PlaylistItem, *db.PlaylistItem, error) {
+	items, matches, err := getItemsAndMatches(ctx, queries, session)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(items) == 0 {
+		return nil, nil, ErrNoItemsLeft
+	}
+
+	rounds := swissRounds(session, len(items))
+	if session.CurrentRound < rounds {
+		if pair := swissPair(availableIn(items, session), matches, session.CurrentRound); pair != nil {
+			return pair, nil, nil
+		}
+	}
+
+	// the round is over, the session too if it was the last one
+	if session.CurrentRound+1 < rounds {
+		if err := advanceRound(ctx, queries, session); err != nil {
+			return nil, nil, err
+		}
+		if pair := swissPair(availableIn(items, session), matches, session.CurrentRound); pair != nil {
+			return pair, nil, nil
+		}
+	}
+
+	winner := swissWinner(items)
+	return nil, &winner, nil
+}
+
+// the number of rounds configured for the session
+// or enough rounds for a single undefeated item if none were configured
+func swissRounds(session *db.Session, nItems int) int64 {
+	if session.Rounds > 0 {
+		return session.Rounds
+	}
+	return int64(math.Ceil(math.Log2(float64(nItems))))
+}
+
+// pairs the available item with the most wins with the closest
+// item in the standings it did not meet before
+func swissPair(available []db.GetRemainingItemsRow, matches []db.Match, round int64) []db.PlaylistItem {
+	if len(available) < 2 {
+		return nil
+	}
+
+	// stable to keep the shuffled order of items with the same number of wins
+	sort.SliceStable(available, func(i, j int) bool {
+		return available[i].Wins > available[j].Wins
+	})
+
+	first := available[0]
+	opponent := available[1]
+	for _, candidate := range available[1:] {
+		if !alreadyMet(matches, first.ID, candidate.ID, round) {
+			opponent = candidate
+			break
+		}
+	}
+
+	return []db.PlaylistItem{
+		remainingItemToPlaylistItem(first),
+		remainingItemToPlaylistItem(opponent),
+	}
+}
+
+// skipped matches only count in the round they were skipped in
+func alreadyMet(matches []db.Match, a, b string, round int64) bool {
+	for _, match := range matches {
+		if match.Outcome == OutcomeSkip && match.RoundNumber != round {
+			continue
+		}
+		if (match.Winner == a && match.Loser == b) || (match.Winner == b && match.Loser == a) {
+			return true
+		}
+	}
+	return false
+}
+
+// the item with the most wins, ties are broken by the fewest losses
+func swissWinner(items []db.GetRemainingItemsRow) db.PlaylistItem {
+	best := items[0]
+	for _, item := range items[1:] {
+		if item.Wins > best.Wins || (item.Wins == best.Wins && item.Losses < best.Losses) {
+			best = item
+		}
+	}
+	return remainingItemToPlaylistItem(best)
+}
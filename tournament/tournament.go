@@ -0,0 +1,211 @@
+// Package tournament implements the pairing logic of FindFavouriteSong sessions.
+//
+// A session is played by repeatedly asking NextPair for the pair to be decided
+// and storing the decision with RecordMatch, until NextPair returns the winner.
+// All state lives in a Store, which is implemented by the queries of the db package.
+// Every function makes several changes to the store, so it should be bound to a transaction.
+package tournament
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"sort"
+
+	"github.com/bafto/FindFavouriteSong/db"
+)
+
+// possible values of session.mode
+const (
+	ModeKnockout          = "knockout"
+	ModeDoubleElimination = "double_elimination"
+	ModeSwiss             = "swiss"
+	ModeRanking           = "ranking"
+)
+
+// possible values of match.outcome
+const (
+	OutcomeWin  = "win"  // winner advances, loser lost
+	OutcomeTie  = "tie"  // both advance
+	OutcomeSkip = "skip" // no result, both are paired again later
+)
+
+// returned by NextPair if neither a pair nor a winner could be determined
+// the session can be fixed with Repair
+var ErrNoItemsLeft = errors.New("no items left in session")
+
+// A Tournament implements the pairing logic of a session mode
+type Tournament interface {
+	// called after a match was inserted into or deleted from the DB
+	// the possible_next_items are already updated by the match trigger
+	// (or reset after a deletion), so this has to be idempotent
+	RecordMatch(ctx context.Context, queries Store, session *db.Session) error
+	// returns the next pair to be decided, advancing session.CurrentRound if necessary
+	// if the session is decided, the winner is returned instead of a pair
+	NextPair(ctx context.Context, queries Store, session *db.Session) (pair []db.PlaylistItem, winner *db.PlaylistItem, err error)
+}
+
+// returns the Tournament of mode or an error if mode is unknown
+func ForMode(mode string) (Tournament, error) {
+	switch mode {
+	case ModeKnockout:
+		return knockoutTournament{}, nil
+	case ModeDoubleElimination:
+		return doubleEliminationTournament{}, nil
+	case ModeSwiss:
+		return swissTournament{}, nil
+	case ModeRanking:
+		return rankingTournament{}, nil
+	default:
+		return nil, fmt.Errorf("unknown tournament mode %s", mode)
+	}
+}
+
+func advanceRound(ctx context.Context, queries Store, session *db.Session) error {
+	if err := queries.SetCurrentRound(ctx, db.SetCurrentRoundParams{
+		ID:           session.ID,
+		CurrentRound: session.CurrentRound + 1,
+	}); err != nil {
+		return fmt.Errorf("error updating current_round in DB: %w", err)
+	}
+	session.CurrentRound++
+	return nil
+}
+
+func remainingItemToPlaylistItem(item db.GetRemainingItemsRow) db.PlaylistItem {
+	return db.PlaylistItem{
+		ID:                item.ID,
+		Title:             item.Title,
+		Artists:           item.Artists,
+		Image:             item.Image,
+		HasValidSpotifyID: item.HasValidSpotifyID,
+	}
+}
+
+// single elimination, every item is eliminated after its first loss
+type knockoutTournament struct{}
+
+func (knockoutTournament) RecordMatch(ctx context.Context, queries Store, session *db.Session) error {
+	return queries.EliminateItemsWithLosses(ctx, db.EliminateItemsWithLossesParams{
+		Session: session.ID,
+		Losses:  1,
+	})
+}
+
+func (knockoutTournament) NextPair(ctx context.Context, queries Store, session *db.Session) ([]db.PlaylistItem, *db.PlaylistItem, error) {
+	items, matches, err := getItemsAndMatches(ctx, queries, session)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if pair := nextPairOf(availableIn(items, session), matches, session.CurrentRound); pair != nil {
+		return pair, nil, nil
+	}
+
+	switch len(items) {
+	case 0:
+		return nil, nil, ErrNoItemsLeft
+	case 1:
+		winner := remainingItemToPlaylistItem(items[0])
+		return nil, &winner, nil
+	}
+
+	// every item played in the current round
+	if err := advanceRound(ctx, queries, session); err != nil {
+		return nil, nil, err
+	}
+	return nextPairOf(availableIn(items, session), matches, session.CurrentRound), nil, nil
+}
+
+// loads the remaining items (ordered by id) and all matches of the session
+func getItemsAndMatches(ctx context.Context, queries Store, session *db.Session) ([]db.GetRemainingItemsRow, []db.Match, error) {
+	items, err := queries.GetRemainingItems(ctx, session.ID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error getting remaining items from DB: %w", err)
+	}
+
+	matches, err := queries.GetMatchesForSession(ctx, session.ID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error getting matches from DB: %w", err)
+	}
+	return items, matches, nil
+}
+
+// returns the items which did not play in the current round yet in the pairing order of the round
+func availableIn(items []db.GetRemainingItemsRow, session *db.Session) []db.GetRemainingItemsRow {
+	return shuffled(notPlayedIn(items, session.CurrentRound), session.RandomSeed, session.CurrentRound)
+}
+
+// shuffles items deterministically, so that the same session state always yields the same pairs
+func shuffled(items []db.GetRemainingItemsRow, seed, round int64) []db.GetRemainingItemsRow {
+	result := slices.Clone(items)
+	gen := rand.New(rand.NewPCG(uint64(seed), uint64(round)))
+	gen.Shuffle(len(result), func(i, j int) {
+		result[i], result[j] = result[j], result[i]
+	})
+	return result
+}
+
+// returns the items which did not play in round yet
+func notPlayedIn(items []db.GetRemainingItemsRow, round int64) []db.GetRemainingItemsRow {
+	result := make([]db.GetRemainingItemsRow, 0, len(items))
+	for _, item := range items {
+		if item.PlayedRound != round {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+// returns the items which were not skipped in round
+func notSkippedIn(items []db.GetRemainingItemsRow, matches []db.Match, round int64) []db.GetRemainingItemsRow {
+	skipped := map[string]struct{}{}
+	for _, match := range matches {
+		if match.Outcome == OutcomeSkip && match.RoundNumber == round {
+			skipped[match.Winner] = struct{}{}
+			skipped[match.Loser] = struct{}{}
+		}
+	}
+
+	result := make([]db.GetRemainingItemsRow, 0, len(items))
+	for _, item := range items {
+		if _, ok := skipped[item.ID]; !ok {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+// like pairOf, but items skipped in round are only paired
+// if there is no pair left without them
+func nextPairOf(items []db.GetRemainingItemsRow, matches []db.Match, round int64) []db.PlaylistItem {
+	if pair := pairOf(notSkippedIn(items, matches, round)); pair != nil {
+		return pair
+	}
+	return pairOf(items)
+}
+
+// returns the next pair out of items or nil if there are less than two items
+// unseeded items are shuffled, so the first two are paired
+// seeded items are paired strongest against weakest, so that strong items meet late
+func pairOf(items []db.GetRemainingItemsRow) []db.PlaylistItem {
+	if len(items) < 2 {
+		return nil
+	}
+
+	first, second := items[0], items[1]
+	if first.Seed.Valid {
+		sorted := slices.Clone(items)
+		sort.SliceStable(sorted, func(i, j int) bool {
+			return sorted[i].Seed.Int64 < sorted[j].Seed.Int64
+		})
+		first, second = sorted[0], sorted[len(sorted)-1]
+	}
+
+	return []db.PlaylistItem{
+		remainingItemToPlaylistItem(first),
+		remainingItemToPlaylistItem(second),
+	}
+}
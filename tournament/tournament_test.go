@@ -0,0 +1,99 @@
+package tournament
+
+import (
+	"database/sql"
+	"slices"
+	"testing"
+
+	"github.com/bafto/FindFavouriteSong/db"
+)
+
+// items which did not play yet, without seeds
+func testItems(ids ...string) []db.GetRemainingItemsRow {
+	items := make([]db.GetRemainingItemsRow, 0, len(ids))
+	for _, id := range ids {
+		items = append(items, db.GetRemainingItemsRow{ID: id, PlayedRound: -1})
+	}
+	return items
+}
+
+func pairIDs(pair []db.PlaylistItem) [2]string {
+	if len(pair) != 2 {
+		return [2]string{}
+	}
+	return [2]string{pair[0].ID, pair[1].ID}
+}
+
+func TestPairingIsReproducible(t *testing.T) {
+	tests := []struct {
+		name   string
+		seed   int64
+		round  int64
+		played []string // items which already played in round
+		want   [2]string
+	}{
+		{name: "seed 1", seed: 1, round: 0, want: [2]string{"c", "h"}},
+		{name: "seed 1 in the next round", seed: 1, round: 1, want: [2]string{"d", "g"}},
+		{name: "seed 2", seed: 2, round: 0, want: [2]string{"e", "a"}},
+		{name: "seed 42", seed: 42, round: 0, want: [2]string{"d", "e"}},
+		{name: "negative seed", seed: -7, round: 1, want: [2]string{"b", "f"}},
+		{name: "played items sit out", seed: 1, round: 0, played: []string{"c", "h"}, want: [2]string{"b", "g"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			items := testItems("a", "b", "c", "d", "e", "f", "g", "h")
+			for i := range items {
+				if slices.Contains(test.played, items[i].ID) {
+					items[i].PlayedRound = test.round
+				}
+			}
+			session := db.Session{RandomSeed: test.seed, CurrentRound: test.round}
+
+			// the same state always yields the same pair
+			for range 3 {
+				pair := nextPairOf(availableIn(items, &session), nil, session.CurrentRound)
+				if got := pairIDs(pair); got != test.want {
+					t.Fatalf("expected pair %v, got %v", test.want, got)
+				}
+			}
+		})
+	}
+}
+
+func TestDifferentSeedsShuffleDifferently(t *testing.T) {
+	items := testItems("a", "b", "c", "d", "e", "f", "g", "h")
+
+	var orders [][]db.GetRemainingItemsRow
+	for _, seed := range []int64{1, 2, 42, -7} {
+		for _, round := range []int64{0, 1} {
+			order := shuffled(items, seed, round)
+			for _, other := range orders {
+				if slices.Equal(order, other) {
+					t.Errorf("seed %d in round %d shuffles like another seed or round: %v", seed, round, order)
+				}
+			}
+			orders = append(orders, order)
+		}
+	}
+
+	if !slices.Equal(items, testItems("a", "b", "c", "d", "e", "f", "g", "h")) {
+		t.Error("shuffled changed its argument")
+	}
+}
+
+// seeded items are paired strongest against weakest, whatever the random seed
+func TestSeededPairingIgnoresRandomSeed(t *testing.T) {
+	items := testItems("a", "b", "c", "d")
+	for i, seed := range []int64{3, 1, 4, 2} {
+		items[i].Seed = sql.NullInt64{Int64: seed, Valid: true}
+	}
+
+	for _, randomSeed := range []int64{1, 2, 42} {
+		session := db.Session{RandomSeed: randomSeed}
+		pair := nextPairOf(availableIn(items, &session), nil, session.CurrentRound)
+		if got, want := pairIDs(pair), [2]string{"b", "c"}; got != want {
+			t.Errorf("random seed %d: expected pair %v, got %v", randomSeed, want, got)
+		}
+	}
+}
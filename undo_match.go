@@ -0,0 +1,124 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/bafto/FindFavouriteSong/db"
+	"github.com/bafto/FindFavouriteSong/tournament"
+	"github.com/gin-gonic/gin"
+)
+
+func (app *App) undoMatchHandler(c *gin.Context) {
+	logger, user, tx, queries, err := app.getLoggerUserTransactionQueries(c)
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+	defer tx.Rollback()
+
+	if !user.CurrentSession.Valid {
+		c.AbortWithError(http.StatusBadRequest, fmt.Errorf("no active session exists"))
+		return
+	}
+
+	sessionID := user.CurrentSession.Int64
+	logger = logger.With("session-id", sessionID)
+
+	session, err := queries.GetSession(c.Request.Context(), sessionID)
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, fmt.Errorf("could not load session from DB: %w", err))
+		return
+	}
+
+	state, status, err := app.undoLatestMatch(c.Request.Context(), logger, tx, queries, &session)
+	if err != nil {
+		c.AbortWithError(status, err)
+		return
+	}
+
+	// present the undone pair again
+	c.JSON(http.StatusOK, state.SelectSongResponse())
+}
+
+// deletes the latest match of session and reverts its effects
+// commits tx and publishes the undone pair, which is to be decided again
+func (app *App) undoLatestMatch(ctx context.Context, logger *slog.Logger, tx *sql.Tx, queries db.Store, session *db.Session) (SessionState, int, error) {
+	previousRound := session.CurrentRound
+	match, err := tournament.UndoLatestMatch(ctx, queries, session)
+	if errors.Is(err, tournament.ErrNoMatch) {
+		return SessionState{}, http.StatusBadRequest, err
+	}
+	if err != nil {
+		return SessionState{}, http.StatusInternalServerError, err
+	}
+	logger = logger.With("match-id", match.ID)
+	if session.CurrentRound != previousRound {
+		logger.Debug("rolled back current round", "from", previousRound, "to", session.CurrentRound)
+	}
+
+	group, err := queries.GetGroupSession(ctx, session.ID)
+	if err == nil {
+		return app.undoGroupMatch(ctx, logger, tx, queries, session, group, match, previousRound)
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return SessionState{}, http.StatusInternalServerError, fmt.Errorf("could not load group session from DB: %w", err)
+	}
+
+	winner, err := queries.GetPlaylistItem(ctx, match.Winner)
+	if err != nil {
+		return SessionState{}, http.StatusInternalServerError, fmt.Errorf("could not load winner of match from DB: %w", err)
+	}
+	loser, err := queries.GetPlaylistItem(ctx, match.Loser)
+	if err != nil {
+		return SessionState{}, http.StatusInternalServerError, fmt.Errorf("could not load loser of match from DB: %w", err)
+	}
+
+	matchesCount, err := queries.CountMatchesForRound(ctx, db.CountMatchesForRoundParams{
+		Session:     session.ID,
+		RoundNumber: session.CurrentRound,
+	})
+	if err != nil {
+		logger.Warn("could not retrieve number of matches", "err", err)
+		matchesCount = 0
+	}
+
+	if status, err := commitTransaction(tx); err != nil {
+		return SessionState{}, status, err
+	}
+	logger.Info("undid match")
+
+	state := SessionState{Round: session.CurrentRound, Matches: matchesCount, Pair: []db.PlaylistItem{winner, loser}}
+	app.publishUndo(session, match, previousRound)
+	app.publishSessionEvent(session, event_pair, state.SelectSongResponse())
+	return state, -1, nil
+}
+
+// helper function for undoLatestMatch
+// the group votes on the undone pair again
+func (app *App) undoGroupMatch(ctx context.Context, logger *slog.Logger, tx *sql.Tx, queries db.Store, session *db.Session, group db.GroupSession, match db.Match, previousRound int64) (SessionState, int, error) {
+	if err := queries.DeleteGroupVotes(ctx, session.ID); err != nil {
+		return SessionState{}, http.StatusInternalServerError, fmt.Errorf("could not delete votes: %w", err)
+	}
+	if err := setGroupPair(ctx, queries, &group, match.Winner, match.Loser); err != nil {
+		return SessionState{}, http.StatusInternalServerError, err
+	}
+
+	state, err := getGroupState(ctx, queries, session, group)
+	if err != nil {
+		return SessionState{}, http.StatusInternalServerError, err
+	}
+
+	if status, err := commitTransaction(tx); err != nil {
+		return SessionState{}, status, err
+	}
+	logger.Info("undid group match")
+
+	app.publishUndo(session, match, previousRound)
+	app.applyGroupProgress(logger, session, group, groupProgress{pairChanged: true, previousRound: session.CurrentRound}, state)
+	return state, -1, nil
+}